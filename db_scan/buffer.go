@@ -0,0 +1,132 @@
+package db_scan
+
+import (
+	"context"
+)
+
+//扫描过程中可在多次扫描之间复用的临时缓冲区
+type scanBuffers struct {
+	reader rowReader
+	cells  []interface{}            //rows.Scan接收参数指向的值
+	datas  []map[string]interface{} //上次扫描的行数据，底层数组中的map可复用
+}
+
+//复用上次扫描的接收参数构造rowReader
+func (b *scanBuffers) rowReader(rows IRows) (*rowReader, error) {
+	columns, err := rowColumns(rows)
+	if nil != err {
+		return nil, err
+	}
+	length := len(columns)
+	if cap(b.cells) < length {
+		b.cells = make([]interface{}, length)
+		b.reader.values = make([]interface{}, length)
+		for i := range b.cells {
+			b.reader.values[i] = &b.cells[i]
+		}
+	}
+	b.reader.rows, b.reader.columns, b.reader.values = rows, columns, b.reader.values[:length]
+	return &b.reader, nil
+}
+
+//提取数据集，retain为true表示行数据会交给调用方，此时不复用行map
+func (b *scanBuffers) extraDatas(ctx context.Context, rows IRows, reader *rowReader, retain bool) ([]map[string]interface{}, error) {
+	var reuse []map[string]interface{}
+	if !retain {
+		reuse = b.datas[:cap(b.datas)]
+	}
+	result, err := readDatas(ctx, rows, reader, reuse)
+	if nil != err {
+		return nil, err
+	}
+	if !retain && cap(result) >= cap(b.datas) {
+		b.datas = result[:0]
+	}
+	return result, nil
+}
+
+//清空缓冲区中保存的数据，保留已分配的容量，扫描器放回sync.Pool之前调用
+func (s *Scanner) Reset() {
+	b := s.buffers
+	if b == nil {
+		return
+	}
+	for i := range b.cells {
+		b.cells[i] = nil
+	}
+	for _, mp := range b.datas[:cap(b.datas)] {
+		for key := range mp {
+			delete(mp, key)
+		}
+	}
+	b.reader.rows, b.reader.columns = nil, nil
+}
+
+//提取数据集，ReuseBuffers模式下复用扫描器的缓冲区，并去除IgnoreColumns中的列，同时返回结果集的列名
+func (s *Scanner) extraDatas(ctx context.Context, rows IRows, target interface{}) ([]map[string]interface{}, []string, error) {
+	if s.ReuseBuffers && s.buffers == nil {
+		s.buffers = &scanBuffers{}
+	}
+	reader, err := s.newRowReader(rows, false)
+	if nil != err {
+		return nil, nil, err
+	}
+	var datas []map[string]interface{}
+	if s.ReuseBuffers {
+		datas, err = s.buffers.extraDatas(ctx, rows, reader, retainsRows(target))
+	} else {
+		datas, err = readDatas(ctx, rows, reader, nil)
+	}
+	if nil != err {
+		return nil, nil, err
+	}
+	for _, row := range datas {
+		s.dropIgnoredColumns(row)
+	}
+	if len(reader.skipped) > 0 {
+		if s.stats != nil {
+			s.stats.SkippedRows = len(reader.skipped)
+		}
+		return datas, reader.columns, &SkippedRowsError{Rows: reader.skipped}
+	}
+	return datas, reader.columns, nil
+}
+
+//构造rowReader，ReuseBuffers模式下复用接收参数，并按DuplicateColumns处理重复列名
+//raw为true时以*sql.RawBytes接收列值，始终新建接收参数，避免改变复用的缓冲区
+func (s *Scanner) newRowReader(rows IRows, raw bool) (*rowReader, error) {
+	var reader *rowReader
+	var err error
+	if s.ReuseBuffers && s.buffers != nil && !raw {
+		reader, err = s.buffers.rowReader(rows)
+	} else {
+		reader, err = newRowReader(rows)
+	}
+	if nil != err {
+		return nil, err
+	}
+	if raw {
+		reader.useRawBytes()
+	}
+	if reader.columns, err = s.resolveColumns(reader.columns); nil != err {
+		return nil, err
+	}
+	reader.skipErrors, reader.skipped = s.SkipScanErrors, nil
+	return reader, nil
+}
+
+//去除行数据中IgnoreColumns指定的列
+func (s *Scanner) dropIgnoredColumns(row map[string]interface{}) {
+	for _, column := range s.IgnoreColumns {
+		delete(row, column)
+	}
+}
+
+//目标对象是否直接持有提取出的行数据
+func retainsRows(target interface{}) bool {
+	switch target.(type) {
+	case *[]map[string]interface{}, *map[string]interface{}:
+		return true
+	}
+	return false
+}
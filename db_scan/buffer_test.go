@@ -0,0 +1,181 @@
+package db_scan
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+func TestScannerReuseBuffers(t *testing.T) {
+	type User struct {
+		ID   int64  `pg:"id"`
+		Name string `pg:"name"`
+	}
+	s := &Scanner{ReuseBuffers: true}
+
+	var users []User
+	err := s.Scan(newMockRows([]string{"id", "name"}, []interface{}{int64(1), []byte("a")}, []interface{}{int64(2), []byte("b")}), &users)
+	Assert(t, err, NilVal())
+	Assert(t, users, Equal([]User{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}))
+
+	//列减少后复用的行map不能残留上次的列
+	err = s.Scan(newMockRows([]string{"id"}, []interface{}{int64(3)}), &users)
+	Assert(t, err, NilVal())
+	Assert(t, users, Equal([]User{{ID: 3}}))
+
+	//交给调用方的行数据不会被后续扫描覆盖
+	var list []map[string]interface{}
+	err = s.Scan(newMockRows([]string{"id"}, []interface{}{int64(4)}), &list)
+	Assert(t, err, NilVal())
+	err = s.Scan(newMockRows([]string{"id", "name"}, []interface{}{int64(5), []byte("c")}, []interface{}{int64(6), nil}, []interface{}{int64(7), nil}), &users)
+	Assert(t, err, NilVal())
+	Assert(t, len(users), Equal(3))
+	Assert(t, list, Equal([]map[string]interface{}{{"id": int64(4)}}))
+
+	var u User
+	err = s.Scan(newMockRows([]string{"id"}), &u)
+	Assert(t, err, Equal(ErrEmptyResult))
+
+	s.Reset()
+	Assert(t, s.buffers.cells[0], NilVal())
+	Assert(t, len(s.buffers.datas[:cap(s.buffers.datas)][0]), Equal(0))
+	err = s.Scan(newMockRows([]string{"name"}, []interface{}{[]byte("d")}), &u)
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{Name: "d"}))
+}
+
+func TestScannerReuseBuffersRaw(t *testing.T) {
+	type User struct {
+		ID   int64  `pg:"id"`
+		Name string `pg:"name"`
+	}
+	s := &Scanner{ReuseBuffers: true}
+	var u User
+	err := s.Scan(newMockRows([]string{"id"}, []interface{}{int64(1)}), &u)
+	Assert(t, err, NilVal())
+
+	//零拷贝扫描不改变复用的接收参数
+	err = s.ScanEachRaw(newMockRows([]string{"id"}, []interface{}{int64(2)}), &u, func() error {
+		return nil
+	})
+	Assert(t, err, NilVal())
+	Assert(t, u.ID, Equal(int64(2)))
+
+	var list []map[string]interface{}
+	err = s.Scan(newMockRows([]string{"id"}, []interface{}{int64(3)}), &list)
+	Assert(t, err, NilVal())
+	Assert(t, list, Equal([]map[string]interface{}{{"id": int64(3)}}))
+
+	u = User{}
+	err = s.Scan(newMockRows([]string{"id", "name"}, []interface{}{int64(4), []byte("a")}), &u)
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{ID: 4, Name: "a"}))
+}
+
+func TestScannerReuseSlice(t *testing.T) {
+	type User struct {
+		ID int64 `pg:"id"`
+	}
+	rows := func(ids ...int64) *mockRows {
+		data := make([][]interface{}, len(ids))
+		for i, id := range ids {
+			data[i] = []interface{}{id}
+		}
+		return newMockRows([]string{"id"}, data...)
+	}
+	s := &Scanner{ReuseSlice: true}
+	users := make([]User, 0, 4)
+	err := s.Scan(rows(1, 2, 3), &users)
+	Assert(t, err, NilVal())
+	Assert(t, users, Equal([]User{{1}, {2}, {3}}))
+	Assert(t, cap(users), Equal(4))
+	first := users
+
+	//复用底层数组，此前的slice被覆盖
+	err = s.Scan(rows(4, 5), &users)
+	Assert(t, err, NilVal())
+	Assert(t, users, Equal([]User{{4}, {5}}))
+	Assert(t, &users[0] == &first[0], Equal(true))
+	Assert(t, first, Equal([]User{{4}, {5}, {3}}))
+
+	//容量不足时分配新的slice
+	err = s.Scan(rows(6, 7, 8, 9, 10), &users)
+	Assert(t, err, NilVal())
+	Assert(t, len(users), Equal(5))
+	Assert(t, first, Equal([]User{{4}, {5}, {3}}))
+
+	//默认不复用
+	users = make([]User, 0, 4)
+	err = Scan(rows(1), &users)
+	Assert(t, err, NilVal())
+	Assert(t, cap(users), Equal(1))
+}
+
+func BenchmarkScanReuseSlice(b *testing.B) {
+	type Person struct {
+		ID   int64  `pg:"id"`
+		Name string `pg:"name"`
+	}
+	columns := []string{"id", "name"}
+	data := make([][]interface{}, 100)
+	for i := range data {
+		data[i] = []interface{}{int64(i), []byte("tencent")}
+	}
+	run := func(b *testing.B, s *Scanner) {
+		b.ReportAllocs()
+		persons := make([]Person, 0, len(data))
+		for i := 0; i < b.N; i++ {
+			if err := s.Scan(newMockRows(columns, data...), &persons); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.Run("default", func(b *testing.B) {
+		run(b, &Scanner{ReuseBuffers: true})
+	})
+	b.Run("reuse", func(b *testing.B) {
+		run(b, &Scanner{ReuseBuffers: true, ReuseSlice: true})
+	})
+}
+
+func BenchmarkScanReuseBuffers(b *testing.B) {
+	type Person struct {
+		ID   int64  `pg:"id"`
+		Name string `pg:"name"`
+		Age  int    `pg:"age"`
+	}
+	columns := []string{"id", "name", "age"}
+	data := make([][]interface{}, 10)
+	for i := range data {
+		data[i] = []interface{}{int64(i), []byte("tencent"), int64(20)}
+	}
+	run := func(b *testing.B, s *Scanner) {
+		b.ReportAllocs()
+		var persons []Person
+		for i := 0; i < b.N; i++ {
+			if err := s.Scan(newMockRows(columns, data...), &persons); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.Run("default", func(b *testing.B) {
+		run(b, &Scanner{})
+	})
+	b.Run("reuse", func(b *testing.B) {
+		run(b, &Scanner{ReuseBuffers: true})
+	})
+	b.Run("pool", func(b *testing.B) {
+		pool := sync.Pool{New: func() interface{} { return &Scanner{ReuseBuffers: true} }}
+		b.ReportAllocs()
+		var persons []Person
+		for i := 0; i < b.N; i++ {
+			s := pool.Get().(*Scanner)
+			if err := s.Scan(newMockRows(columns, data...), &persons); err != nil {
+				b.Fatal(err)
+			}
+			s.Reset()
+			pool.Put(s)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package db_scan
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var ErrDuplicateColumn = errors.New("结果集包含重复的列名")
+
+//结果集中出现重复列名（如JOIN多个表的id列）时的处理方式
+type DuplicatePolicy int
+
+const (
+	DuplicateKeepLast DuplicatePolicy = iota //保留最后一列的值，与database/sql按列名取值的行为一致
+	DuplicateError                           //返回ErrDuplicateColumn
+	DuplicateRename                          //重复的列依次重命名为"列名_2"、"列名_3"……
+)
+
+//按DuplicateColumns处理重复的列名，重命名时不修改rows返回的切片
+func (s *Scanner) resolveColumns(columns []string) ([]string, error) {
+	if s.DuplicateColumns == DuplicateKeepLast {
+		return columns, nil
+	}
+	seen := make(map[string]int, len(columns))
+	for _, column := range columns {
+		seen[column]++
+	}
+	var duplicated []string
+	for _, column := range columns {
+		if seen[column] > 1 {
+			duplicated = append(duplicated, column)
+			seen[column] = 0
+		}
+	}
+	if len(duplicated) == 0 {
+		return columns, nil
+	}
+	if s.DuplicateColumns == DuplicateError {
+		return nil, fmt.Errorf("%w: %v", ErrDuplicateColumn, duplicated)
+	}
+	renamed := make([]string, len(columns))
+	used := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		used[column] = true
+	}
+	counts := make(map[string]int, len(duplicated))
+	for i, column := range columns {
+		counts[column]++
+		if counts[column] == 1 {
+			renamed[i] = column
+			continue
+		}
+		name := column + "_" + strconv.Itoa(counts[column])
+		for used[name] {
+			counts[column]++
+			name = column + "_" + strconv.Itoa(counts[column])
+		}
+		used[name] = true
+		renamed[i] = name
+	}
+	return renamed, nil
+}
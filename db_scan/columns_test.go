@@ -0,0 +1,39 @@
+package db_scan
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+func TestScannerDuplicateColumns(t *testing.T) {
+	type Row struct {
+		ID     int64 `pg:"id"`
+		UserID int64 `pg:"id_2"`
+	}
+	newRows := func() *mockRows {
+		return newMockRows([]string{"id", "name", "id"}, []interface{}{int64(1), []byte("a"), int64(9)})
+	}
+	var r Row
+	err := Scan(newRows(), &r)
+	Assert(t, err, NilVal())
+	Assert(t, r, Equal(Row{ID: 9}))
+
+	err = (&Scanner{DuplicateColumns: DuplicateError}).Scan(newRows(), &r)
+	Assert(t, errors.Is(err, ErrDuplicateColumn), Equal(true))
+
+	r = Row{}
+	rows := newRows()
+	err = (&Scanner{DuplicateColumns: DuplicateRename}).Scan(rows, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r, Equal(Row{ID: 1, UserID: 9}))
+	Assert(t, rows.columns, Equal([]string{"id", "name", "id"}))
+
+	columns, err := (&Scanner{DuplicateColumns: DuplicateRename}).resolveColumns([]string{"id", "id", "id_2", "id"})
+	Assert(t, err, NilVal())
+	Assert(t, columns, Equal([]string{"id", "id_3", "id_2", "id_4"}))
+
+	err = (&Scanner{DuplicateColumns: DuplicateError}).ScanEach(newRows(), &r, func() error { return nil })
+	Assert(t, errors.Is(err, ErrDuplicateColumn), Equal(true))
+}
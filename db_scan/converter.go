@@ -0,0 +1,221 @@
+package db_scan
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//自定义类型转换函数，src为驱动返回的原始值，返回值需可赋值或可转换为目标字段类型
+type ConverterFunc func(src interface{}) (interface{}, error)
+
+var ErrUnknownTransform = errors.New("未注册的字段转换")
+
+//字段类型（或其指针）实现该接口时，以驱动返回的原始值（NULL为nil）调用UnmarshalColumn，跳过内置转换
+//相比sql.Scanner接收的是已提取出的值，如[]byte、int64、time.Time；*T字段的列为NULL时置为nil，不调用
+type ColumnUnmarshaler interface {
+	UnmarshalColumn(src interface{}) error
+}
+
+//字段转换函数，在值转换完成后对字段做规范化处理，如去除空格、转小写
+type TransformFunc func(field reflect.Value) error
+
+var (
+	convertersLock sync.RWMutex
+	converters     = make(map[reflect.Type]ConverterFunc)
+	convertersLen  int32 //已注册的转换函数数量，没有注册时转换无需加锁查找
+
+	enumsLock sync.RWMutex
+	enums     = make(map[reflect.Type]map[int64]string)
+
+	transformsLock sync.RWMutex
+	transforms     = map[string]TransformFunc{
+		"trim":  stringTransform(strings.TrimSpace),
+		"lower": stringTransform(strings.ToLower),
+		"upper": stringTransform(strings.ToUpper),
+	}
+)
+
+//获取字段对应的ColumnUnmarshaler，规则与asSqlScanner一致，traits为字段类型实现的转换接口
+func asColumnUnmarshaler(rTargetVal reflect.Value, traits typeTraits) (ColumnUnmarshaler, bool) {
+	if traits&traitUnmarshaler == 0 {
+		return nil, false
+	}
+	if rTargetVal.CanAddr() {
+		if unmarshaler, ok := rTargetVal.Addr().Interface().(ColumnUnmarshaler); ok {
+			return unmarshaler, true
+		}
+	}
+	if rTargetVal.Kind() == reflect.Ptr && rTargetVal.IsNil() {
+		return nil, false
+	}
+	unmarshaler, ok := rTargetVal.Interface().(ColumnUnmarshaler)
+	return unmarshaler, ok
+}
+
+//注册自定义类型转换，按目标字段的类型匹配，优先于内置转换（包括NULL处理）
+//通常在init中注册，注册与扫描可并发进行
+func RegisterConverter(t reflect.Type, fn ConverterFunc) {
+	convertersLock.Lock()
+	defer convertersLock.Unlock()
+	defer func() {
+		atomic.StoreInt32(&convertersLen, int32(len(converters)))
+	}()
+	if fn == nil {
+		delete(converters, t)
+		return
+	}
+	converters[t] = fn
+}
+
+//查找目标类型注册的转换函数
+func lookupConverter(t reflect.Type) (ConverterFunc, bool) {
+	if atomic.LoadInt32(&convertersLen) == 0 {
+		return nil, false
+	}
+	convertersLock.RLock()
+	defer convertersLock.RUnlock()
+	fn, ok := converters[t]
+	return fn, ok
+}
+
+//使用自定义转换函数设值，指针字段在返回值可赋值或可转换为其指向的类型时分配新值
+func handleCustomConvert(fn ConverterFunc, sourceVal interface{}, rTargetVal reflect.Value) error {
+	result, err := fn(sourceVal)
+	if err != nil {
+		return err
+	}
+	targetType := rTargetVal.Type()
+	if result == nil {
+		rTargetVal.Set(reflect.Zero(targetType))
+		return nil
+	}
+	resultObj := reflect.ValueOf(result)
+	if setConverted(resultObj, rTargetVal) {
+		return nil
+	}
+	if targetType.Kind() == reflect.Ptr {
+		targetInstance := reflect.New(targetType.Elem())
+		if setConverted(resultObj, targetInstance.Elem()) {
+			rTargetVal.Set(targetInstance)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: converter returned %s for %s", ErrConvertValue, resultObj.Type(), targetType)
+}
+
+//返回值可赋值或可转换为目标类型时设值
+func setConverted(resultObj, rTargetVal reflect.Value) bool {
+	targetType := rTargetVal.Type()
+	switch {
+	case resultObj.Type().AssignableTo(targetType):
+		rTargetVal.Set(resultObj)
+	case resultObj.Type().ConvertibleTo(targetType):
+		rTargetVal.Set(resultObj.Convert(targetType))
+	default:
+		return false
+	}
+	return true
+}
+
+//注册整数枚举到字符串的映射，fieldType为string类型的字段（如type Status string）
+//整数列（或数字文本列）转换到该类型的字段时替换为对应的描述，未登记的值返回ErrConvertValue；传入nil取消注册
+func RegisterEnum(fieldType reflect.Type, names map[int64]string) {
+	enumsLock.Lock()
+	defer enumsLock.Unlock()
+	if names == nil {
+		delete(enums, fieldType)
+		return
+	}
+	copied := make(map[int64]string, len(names))
+	for value, name := range names {
+		copied[value] = name
+	}
+	enums[fieldType] = copied
+}
+
+//按注册的枚举映射设值，源值不是整数时返回false交由内置转换处理
+func handleConvertEnum(sourceVal interface{}, rTargetVal reflect.Value) (bool, error) {
+	if rTargetVal.Kind() != reflect.String {
+		return false, nil
+	}
+	enumsLock.RLock()
+	names, ok := enums[rTargetVal.Type()]
+	enumsLock.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	sourceObj := reflect.ValueOf(sourceVal)
+	switch {
+	case isSignedInteger(sourceObj.Kind()):
+		return true, setEnum(names, sourceObj.Int(), rTargetVal)
+	case isUnsignedInteger(sourceObj.Kind()):
+		//超出int64范围的值转换后会变为负数，误匹配负数枚举值
+		if sourceObj.Uint() > math.MaxInt64 {
+			return true, fmt.Errorf("%w: enum %d -> %s", ErrConvertValue, sourceObj.Uint(), rTargetVal.Type())
+		}
+		return true, setEnum(names, int64(sourceObj.Uint()), rTargetVal)
+	case sourceObj.Kind() == reflect.String:
+		return handleConvertEnumText(names, sourceObj.String(), rTargetVal)
+	case isBytesType(sourceObj.Type()):
+		return handleConvertEnumText(names, string(sourceObj.Bytes()), rTargetVal)
+	}
+	return false, nil
+}
+
+//文本源值为数字时按枚举映射设值，否则视为已是描述文本
+func handleConvertEnumText(names map[int64]string, text string, rTargetVal reflect.Value) (bool, error) {
+	value, err := strconv.ParseInt(text, 10, 64)
+	if nil != err {
+		return false, nil
+	}
+	return true, setEnum(names, value, rTargetVal)
+}
+
+func setEnum(names map[int64]string, value int64, rTargetVal reflect.Value) error {
+	name, ok := names[value]
+	if !ok {
+		return fmt.Errorf("%w: enum %d -> %s", ErrConvertValue, value, rTargetVal.Type())
+	}
+	rTargetVal.SetString(name)
+	return nil
+}
+
+//注册字段转换，标签中以transform=name使用，如pg:"email,transform=lower"
+//内置trim、lower、upper，注册同名转换会覆盖内置实现
+func RegisterTransform(name string, fn TransformFunc) {
+	transformsLock.Lock()
+	defer transformsLock.Unlock()
+	if fn == nil {
+		delete(transforms, name)
+		return
+	}
+	transforms[name] = fn
+}
+
+//执行字段标签中指定的转换
+func applyTransform(name string, field reflect.Value) error {
+	transformsLock.RLock()
+	fn, ok := transforms[name]
+	transformsLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTransform, name)
+	}
+	return fn(field)
+}
+
+//作用于string字段（或其指针）的转换，其他类型的字段保持不变
+func stringTransform(fn func(string) string) TransformFunc {
+	return func(field reflect.Value) error {
+		field = reflect.Indirect(field)
+		if field.IsValid() && field.Kind() == reflect.String {
+			field.SetString(fn(field.String()))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,200 @@
+package db_scan
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+type level int
+
+func TestRegisterConverter(t *testing.T) {
+	RegisterConverter(reflect.TypeOf(level(0)), func(src interface{}) (interface{}, error) {
+		switch strings.ToLower(string(src.([]byte))) {
+		case "low":
+			return 1, nil
+		case "high":
+			return 2, nil
+		}
+		return nil, ErrConvertValue
+	})
+	RegisterConverter(reflect.TypeOf(net.IP{}), func(src interface{}) (interface{}, error) {
+		if src == nil {
+			return nil, nil
+		}
+		return net.ParseIP(string(src.([]byte))), nil
+	})
+	defer RegisterConverter(reflect.TypeOf(level(0)), nil)
+	defer RegisterConverter(reflect.TypeOf(net.IP{}), nil)
+
+	type Host struct {
+		Level  level   `pg:"level"`
+		IP     net.IP  `pg:"ip"`
+		Backup *net.IP `pg:"backup"`
+	}
+	var h Host
+	err := defaultScanner.singleResult(map[string]interface{}{
+		"level":  []byte("HIGH"),
+		"ip":     []byte("10.0.0.1"),
+		"backup": []byte("10.0.0.2"),
+	}, &h)
+	Assert(t, err, NilVal())
+	Assert(t, h.Level, Equal(level(2)))
+	Assert(t, h.IP.String(), Equal("10.0.0.1"))
+	Assert(t, h.Backup.String(), Equal("10.0.0.2"))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"level": []byte("unknown")}, &h)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	RegisterConverter(reflect.TypeOf(level(0)), func(src interface{}) (interface{}, error) {
+		return "high", nil
+	})
+	err = defaultScanner.singleResult(map[string]interface{}{"level": []byte("high")}, &h)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	//注册了转换函数的结构体类型按单列值接收
+	type point struct {
+		X, Y int
+	}
+	RegisterConverter(reflect.TypeOf(point{}), func(src interface{}) (interface{}, error) {
+		var p point
+		_, err := fmt.Sscanf(string(src.([]byte)), "(%d,%d)", &p.X, &p.Y)
+		return p, err
+	})
+	defer RegisterConverter(reflect.TypeOf(point{}), nil)
+	var p point
+	err = Scan(newMockRows([]string{"location"}, []interface{}{[]byte("(1,2)")}), &p)
+	Assert(t, err, NilVal())
+	Assert(t, p, Equal(point{X: 1, Y: 2}))
+}
+
+func TestRegisterTransform(t *testing.T) {
+	RegisterTransform("mask", func(field reflect.Value) error {
+		if field.Kind() != reflect.String {
+			return ErrConvertValue
+		}
+		if s := field.String(); len(s) > 4 {
+			field.SetString(strings.Repeat("*", len(s)-4) + s[len(s)-4:])
+		}
+		return nil
+	})
+	defer RegisterTransform("mask", nil)
+
+	type Contact struct {
+		Name  string  `pg:"name,transform=trim"`
+		Email *string `pg:"email,transform=lower"`
+		Phone string  `pg:"phone,transform=mask"`
+		Code  string  `pg:"code,transform=upper"`
+		Age   int     `pg:"age,transform=mask"`
+	}
+	var c Contact
+	err := defaultScanner.singleResult(map[string]interface{}{
+		"name":  []byte("  Tom \n"),
+		"email": "Tom@Example.COM",
+		"phone": []byte("13800001234"),
+		"code":  []byte("cn"),
+	}, &c)
+	Assert(t, err, NilVal())
+	Assert(t, c.Name, Equal("Tom"))
+	Assert(t, *c.Email, Equal("tom@example.com"))
+	Assert(t, c.Phone, Equal("*******1234"))
+	Assert(t, c.Code, Equal("CN"))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"email": nil, "age": int64(3)}, &c)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	Assert(t, c.Email == nil, Equal(true))
+
+	type Unknown struct {
+		Name string `pg:"name,transform=reverse"`
+	}
+	err = defaultScanner.singleResult(map[string]interface{}{"name": []byte("a")}, &Unknown{})
+	Assert(t, errors.Is(err, ErrUnknownTransform), Equal(true))
+}
+
+type orderStatus string
+
+func TestRegisterEnum(t *testing.T) {
+	RegisterEnum(reflect.TypeOf(orderStatus("")), map[int64]string{0: "pending", 1: "paid", 2: "shipped"})
+	defer RegisterEnum(reflect.TypeOf(orderStatus("")), nil)
+
+	type Order struct {
+		Status orderStatus  `pg:"status"`
+		Prev   *orderStatus `pg:"prev"`
+		Label  orderStatus  `pg:"label"`
+		Raw    string       `pg:"raw"`
+	}
+	var o Order
+	err := defaultScanner.singleResult(map[string]interface{}{
+		"status": int64(2),
+		"prev":   []byte("1"),
+		"label":  []byte("refunded"),
+		"raw":    int64(2),
+	}, &o)
+	Assert(t, err, NilVal())
+	Assert(t, o.Status, Equal(orderStatus("shipped")))
+	Assert(t, *o.Prev, Equal(orderStatus("paid")))
+	Assert(t, o.Label, Equal(orderStatus("refunded")))
+	Assert(t, o.Raw, Equal("2"))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"status": int32(9)}, &o)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	//超出int64范围的无符号值不能回绕为负数匹配到-1
+	RegisterEnum(reflect.TypeOf(orderStatus("")), map[int64]string{-1: "deleted", 0: "pending"})
+	o = Order{}
+	err = defaultScanner.singleResult(map[string]interface{}{"status": uint64(math.MaxUint64)}, &o)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	Assert(t, o.Status, Equal(orderStatus("")))
+	err = defaultScanner.singleResult(map[string]interface{}{"status": uint(0)}, &o)
+	Assert(t, err, NilVal())
+	Assert(t, o.Status, Equal(orderStatus("pending")))
+}
+
+//记录驱动原始值类型的字段
+type columnValue struct {
+	Kind  string
+	Value string
+}
+
+func (r *columnValue) UnmarshalColumn(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*r = columnValue{Kind: "null"}
+	case []byte:
+		*r = columnValue{Kind: "bytes", Value: string(v)}
+	case int64:
+		*r = columnValue{Kind: "int", Value: strconv.FormatInt(v, 10)}
+	default:
+		return ErrConvertValue
+	}
+	return nil
+}
+
+func TestColumnUnmarshaler(t *testing.T) {
+	type Record struct {
+		Name  columnValue  `pg:"name"`
+		Count columnValue  `pg:"count"`
+		Empty columnValue  `pg:"empty"`
+		Ptr   *columnValue `pg:"count"`
+		Nil   *columnValue `pg:"empty"`
+	}
+	mp := map[string]interface{}{"name": []byte("tc"), "count": int64(3), "empty": nil}
+	var r Record
+	err := defaultScanner.singleResult(mp, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r.Name, Equal(columnValue{Kind: "bytes", Value: "tc"}))
+	Assert(t, r.Count, Equal(columnValue{Kind: "int", Value: "3"}))
+	Assert(t, r.Empty, Equal(columnValue{Kind: "null"}))
+	Assert(t, *r.Ptr, Equal(columnValue{Kind: "int", Value: "3"}))
+	Assert(t, r.Nil == nil, Equal(true))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"name": 1.5}, &r)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
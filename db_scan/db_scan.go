@@ -1,9 +1,18 @@
 package db_scan
 
 import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,13 +22,43 @@ var (
 	ErrUnSupportTypeConvert = errors.New("暂不支持的类型转换")
 	ErrSliceToString        = errors.New("slice转string失败")
 	ErrEmptyResult          = errors.New("结果为空")
+	ErrMultipleColumns      = errors.New("结果集包含多列，无法扫描为单值")
+	ErrMultipleResults      = errors.New("结果集包含多行")
+	ErrValueOverflow        = errors.New("数值超出字段类型范围")
+	ErrScanPanic            = errors.New("扫描过程中发生panic")
+	ErrNilRows              = errors.New("数据集为nil")
 )
 
 const (
-	DefaultTagName    = "pg"                  //默认标签名称
-	DefaultTimeFormat = "2006-01-02 15:04:05" //默认时间格式
+	DefaultTagName     = "pg"                  //默认标签名称
+	DefaultTimeFormat  = "2006-01-02 15:04:05" //默认时间格式
+	DefaultDateFormat  = "2006-01-02"          //默认日期格式
+	DefaultClockFormat = "15:04:05"            //TIME列（不含日期）的时间格式，解析为日期为0000-01-01的time.Time
+	SkipTagValue       = "-"                   //标签值为"-"的字段不参与扫描
+	ColumnSeparator    = "|"                   //标签中分隔候选列名的字符，取结果中第一个存在的列，如pg:"created_at|creation_time"
+	TagOptionPrefix    = "prefix"              //标签选项：结构体字段按"标签名+子字段列名"匹配列，如pg:"user_,prefix"
+	TagOptionUnixNano  = "unixnano"            //标签选项：时间转换为整数字段时使用纳秒时间戳，如pg:"created_at,unixnano"
+	TagOptionDefault   = "default"             //标签选项：列为NULL或不存在时使用的默认值，值中不能包含","，如pg:"status,default=active"
+	TagOptionTransform = "transform"           //标签选项：值转换后执行RegisterTransform注册的字段转换，如pg:"email,transform=lower"
+	TagOptionExtra     = "extra"               //标签选项：map[string]interface{}字段接收未匹配到其他字段的列，如pg:",extra"
+	TagOptionBase      = "base"                //标签选项：文本解析为整数字段时使用的进制，0表示按0x、0o、0b前缀自动识别，如pg:"flags,base=16"
 )
 
+//提取数据集时检查ctx是否取消的行数间隔
+const ctxCheckInterval = 64
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	ipType       = reflect.TypeOf(net.IP(nil))
+	macType      = reflect.TypeOf(net.HardwareAddr(nil))
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+//未配置时间格式时，字符串解析为时间依次尝试的格式
+var defaultTimeLayouts = []string{DefaultTimeFormat, time.RFC3339Nano, DefaultDateFormat}
+
 //database/sql的rows抽象接口
 type IRows interface {
 	Close() error
@@ -28,6 +67,11 @@ type IRows interface {
 	Scan(dest ...interface{}) error
 }
 
+//目标结构体实现该接口时，每行扫描完成后调用SetDefault，用于填充默认值或计算字段
+type DefaultSetter interface {
+	SetDefault()
+}
+
 //获取普通对象的类型
 func getObjectType(obj interface{}) reflect.Type {
 	return reflect.TypeOf(obj)
@@ -52,6 +96,10 @@ func isFloat(k reflect.Kind) bool {
 	return k == reflect.Float32 || k == reflect.Float64
 }
 
+func isNumeric(k reflect.Kind) bool {
+	return isInteger(k) || isFloat(k) || k == reflect.Complex64 || k == reflect.Complex128
+}
+
 func isInteger(k reflect.Kind) bool {
 	return isSignedInteger(k) || isUnsignedInteger(k)
 }
@@ -64,81 +112,563 @@ func isUnsignedInteger(k reflect.Kind) bool {
 	return k >= reflect.Uint && k <= reflect.Uintptr
 }
 
+//是否为可直接承载单列值的类型及其指针，包括基础类型、time.Time、big.Int、big.Float、interface{}，
+//以及实现了sql.Scanner或ColumnUnmarshaler、注册了转换函数的类型，如SELECT MAX(x)扫描到sql.NullInt64
+func isScalarType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		if _, ok := lookupConverter(t); ok {
+			return true
+		}
+		t = t.Elem()
+	}
+	if t == timeType || t == bigIntType || t == bigFloatType || isEmptyInterface(t) {
+		return true
+	}
+	if _, ok := lookupConverter(t); ok {
+		return true
+	}
+	if ptr := reflect.PtrTo(t); ptr.Implements(sqlScannerType) || ptr.Implements(columnUnmarshalerType) {
+		return true
+	}
+	k := t.Kind()
+	return k == reflect.Bool || k == reflect.String || isInteger(k) || isFloat(k)
+}
+
+//文本值是否为NullLiterals中视为NULL的字面量
+func (s *Scanner) isNullLiteral(sourceVal interface{}) bool {
+	if len(s.NullLiterals) == 0 {
+		return false
+	}
+	text, ok := textValue(sourceVal)
+	if !ok {
+		return false
+	}
+	for _, literal := range s.NullLiterals {
+		if text == literal {
+			return true
+		}
+	}
+	return false
+}
+
+//获取[]byte或string源值的文本
+func textValue(sourceVal interface{}) (string, bool) {
+	switch v := sourceVal.(type) {
+	case []byte:
+		return string(v), true
+	case string:
+		return v, true
+	}
+	return "", false
+}
+
+//是否为空的文本值（空[]byte或空字符串）
+func isEmptyText(sourceVal interface{}) bool {
+	switch v := sourceVal.(type) {
+	case []byte:
+		return len(v) == 0
+	case string:
+		return v == ""
+	}
+	return false
+}
+
+//是否为interface{}类型
+func isEmptyInterface(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() == 0
+}
+
 //数据集扫描
 func Scan(rows IRows, target interface{}) error {
-	if nil == target || getObjectValue(target).IsNil() || getObjectType(target).Kind() != reflect.Ptr {
+	return defaultScanner.Scan(rows, target)
+}
+
+//数据集扫描，出错时panic，适用于测试及脚本
+func MustScan(rows IRows, target interface{}) {
+	defaultScanner.MustScan(rows, target)
+}
+
+//使用扫描器的配置进行数据集扫描，出错时panic
+func (s *Scanner) MustScan(rows IRows, target interface{}) {
+	if err := s.Scan(rows, target); err != nil {
+		panic(err)
+	}
+}
+
+//数据集扫描，扫描过程中的panic（如驱动返回异常值导致反射操作失败）转换为ErrScanPanic返回，适用于不可信的数据源
+func SafeScan(rows IRows, target interface{}) error {
+	return defaultScanner.SafeScan(rows, target)
+}
+
+//使用扫描器的配置进行数据集扫描，panic转换为ErrScanPanic返回，rows仍会被关闭
+func (s *Scanner) SafeScan(rows IRows, target interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrScanPanic, r)
+		}
+	}()
+	return s.Scan(rows, target)
+}
+
+//判断错误是否为结果为空（ErrEmptyResult）
+func IsEmptyResult(err error) bool {
+	return errors.Is(err, ErrEmptyResult)
+}
+
+//使用扫描器的配置进行数据集扫描
+func (s *Scanner) Scan(rows IRows, target interface{}) error {
+	return s.ScanContext(context.Background(), rows, target)
+}
+
+//可取消的数据集扫描，ctx取消时关闭rows并返回ctx.Err()
+func ScanContext(ctx context.Context, rows IRows, target interface{}) error {
+	return defaultScanner.ScanContext(ctx, rows, target)
+}
+
+//使用扫描器的配置进行可取消的数据集扫描
+func (s *Scanner) ScanContext(ctx context.Context, rows IRows, target interface{}) (err error) {
+	defer closeRows(rows, &err)
+	_, err = s.scanN(ctx, rows, target)
+	return err
+}
+
+//数据集扫描，扫描结束后不关闭rows，由调用方自行管理
+func ScanNoClose(rows IRows, target interface{}) error {
+	return defaultScanner.ScanNoClose(rows, target)
+}
+
+//使用扫描器的配置进行数据集扫描，扫描结束后不关闭rows
+func (s *Scanner) ScanNoClose(rows IRows, target interface{}) error {
+	_, err := s.scanN(context.Background(), rows, target)
+	return err
+}
+
+//数据集扫描，同时返回写入目标对象的行数
+func ScanN(rows IRows, target interface{}) (int, error) {
+	return defaultScanner.ScanN(rows, target)
+}
+
+//使用扫描器的配置进行数据集扫描，同时返回写入目标对象的行数
+func (s *Scanner) ScanN(rows IRows, target interface{}) (n int, err error) {
+	defer closeRows(rows, &err)
+	return s.scanN(context.Background(), rows, target)
+}
+
+//字段是否在ScanFields指定的列中，主列名及候选列名任一命中即可
+func (s *Scanner) selected(field fieldInfo) bool {
+	if s.onlyFields == nil || s.onlyFields[field.column] {
+		return true
+	}
+	for _, alt := range field.alts {
+		if s.onlyFields[alt] {
+			return true
+		}
+	}
+	return false
+}
+
+//关闭数据集，扫描出错时优先返回扫描错误，否则返回关闭时的错误
+func closeRows(rows IRows, err *error) {
+	if isNilRows(rows) {
+		return
+	}
+	if closeErr := rows.Close(); nil == *err {
+		*err = closeErr
+	}
+}
+
+func (s *Scanner) scanN(ctx context.Context, rows IRows, target interface{}) (int, error) {
+	if !isSettableTarget(target) {
+		return 0, ErrTargetNotSettable
+	}
+	datas, columns, err := s.extraDatas(ctx, rows, target)
+	skipped, partial := err.(*SkippedRowsError)
+	if nil != err && !partial {
+		return 0, err
+	}
+	n, err := s.withColumns(columns).applyResults(datas, target)
+	if nil == err && partial {
+		return n, skipped
+	}
+	return n, err
+}
+
+//扫描有且仅有一行的数据集，无数据返回ErrEmptyResult，多行返回ErrMultipleResults
+func ScanOne(rows IRows, target interface{}) error {
+	return defaultScanner.ScanOne(rows, target)
+}
+
+//使用扫描器的配置扫描有且仅有一行的数据集
+func (s *Scanner) ScanOne(rows IRows, target interface{}) (err error) {
+	defer closeRows(rows, &err)
+	if !isSettableTarget(target) {
 		return ErrTargetNotSettable
 	}
-	datas, err := ExtraDatasFromRows(rows)
-	if nil != err {
+	datas, columns, err := s.extraDatas(context.Background(), rows, target)
+	skipped, partial := err.(*SkippedRowsError)
+	if nil != err && !partial {
 		return err
 	}
+	//SkipScanErrors模式下按跳过后剩余的行判断行数
+	switch {
+	case len(datas) == 0:
+		return ErrEmptyResult
+	case len(datas) > 1:
+		return ErrMultipleResults
+	}
+	if _, err = s.withColumns(columns).applyResults(datas, target); nil == err && partial {
+		return skipped
+	}
+	return err
+}
+
+//目标对象是否为可设值的非空指针
+func isSettableTarget(target interface{}) bool {
+	return nil != target && getObjectType(target).Kind() == reflect.Ptr && !getObjectValue(target).IsNil()
+}
+
+//将提取出的数据写入目标对象，返回写入的行数
+func (s *Scanner) applyResults(datas []map[string]interface{}, target interface{}) (int, error) {
+	//map目标直接使用提取出的数据
+	if mapTarget, ok := target.(*[]map[string]interface{}); ok {
+		if nil == datas {
+			s.initEmptySlice(target)
+			return 0, nil
+		}
+		if s.OnRow != nil {
+			for i, row := range datas {
+				if err := s.OnRow(i, row); err != nil {
+					return 0, err
+				}
+			}
+		}
+		*mapTarget = datas
+		return len(datas), nil
+	}
 	switch getPtrObjectType(target).Kind() {
 	case reflect.Slice:
 		if nil == datas {
-			return nil
+			s.initEmptySlice(target)
+			return 0, nil
+		}
+		if err := s.checkColumns(datas[0], target); nil != err {
+			return 0, err
 		}
-		err = multiResults(datas, target)
+		s.collectStats(datas[0], target)
+		if err := s.multiResults(datas, target); nil != err {
+			if _, partial := err.(*MultiError); partial {
+				return len(datas), err
+			}
+			return 0, err
+		}
+		return len(datas), nil
 	default:
 		if nil == datas {
-			return ErrEmptyResult
+			if s.AllowEmpty {
+				return 0, nil
+			}
+			return 0, ErrEmptyResult
+		}
+		if s.SingleStrict && len(datas) > 1 {
+			return 0, ErrMultipleResults
 		}
-		err = singleResult(datas[0], target)
+		if err := s.checkColumns(datas[0], target); nil != err {
+			return 0, err
+		}
+		s.collectStats(datas[0], target)
+		if err := s.rowResult(datas[0], target); nil != err {
+			if errs, partial := err.(fieldErrors); partial {
+				return 1, &MultiError{Rows: []*RowError{{Row: 0, Errors: errs}}}
+			}
+			return 0, err
+		}
+		return 1, nil
 	}
-	return err
 }
 
-func ExtraDatasFromRows(rows IRows) ([]map[string]interface{}, error) {
-	var result []map[string]interface{}
-	columns, err := rows.Columns()
-	if nil != err {
-		return nil, err
+//InitEmptySlice模式下没有数据时将slice目标设为长度为0的非nil slice
+func (s *Scanner) initEmptySlice(target interface{}) {
+	if s.InitEmptySlice {
+		valueObj := getPtrObjectValue(target)
+		valueObj.Set(reflect.MakeSlice(valueObj.Type(), 0, 0))
+	}
+}
+
+//单行数据写入非slice的目标对象
+func (s *Scanner) rowResult(result map[string]interface{}, target interface{}) error {
+	if mapTarget, ok := target.(*map[string]interface{}); ok {
+		*mapTarget = result
+		return nil
+	}
+	//基础类型目标直接接收单列的值，如SELECT COUNT(*)
+	if isScalarType(getPtrObjectType(target)) {
+		return s.scalarResult(result, getPtrObjectValue(target))
+	}
+	return s.singleResult(result, target)
+}
+
+//rows是否为nil，包括nil指针（如查询出错时返回的*sql.Rows）
+func isNilRows(rows IRows) bool {
+	if rows == nil {
+		return true
+	}
+	valueObj := reflect.ValueOf(rows)
+	return valueObj.Kind() == reflect.Ptr && valueObj.IsNil()
+}
+
+//获取数据集的列名，rows为nil时返回ErrNilRows
+func rowColumns(rows IRows) ([]string, error) {
+	if isNilRows(rows) {
+		return nil, ErrNilRows
 	}
-	length := len(columns)
+	return rows.Columns()
+}
+
+//构造rows.Scan使用的接收参数
+func newScanDest(length int) []interface{} {
 	values := make([]interface{}, length)
 	for i := 0; i < length; i++ {
 		values[i] = new(interface{})
 	}
+	return values
+}
 
-	for rows.Next() {
-		err = rows.Scan(values...)
+//提取数据集，按列名保存每行数据
+func ExtraDatasFromRows(rows IRows) ([]map[string]interface{}, error) {
+	return extraDatas(context.Background(), rows)
+}
+
+//提取数据集，每隔ctxCheckInterval行检查一次ctx是否已取消
+func extraDatas(ctx context.Context, rows IRows) ([]map[string]interface{}, error) {
+	reader, err := newRowReader(rows)
+	if nil != err {
+		return nil, err
+	}
+	return readDatas(ctx, rows, reader, nil)
+}
+
+//读取全部数据行，reuse中的map按行号复用；没有数据时返回nil
+func readDatas(ctx context.Context, rows IRows, reader *rowReader, reuse []map[string]interface{}) ([]map[string]interface{}, error) {
+	result := reuse[:0]
+	for count := 0; rows.Next(); count++ {
+		if count%ctxCheckInterval == 0 {
+			if err := ctx.Err(); nil != err {
+				return nil, err
+			}
+		}
+		var mp map[string]interface{}
+		if len(result) < len(reuse) {
+			mp = reuse[len(result)]
+		}
+		mp, err := reader.readInto(mp)
 		if nil != err {
+			if reader.skipErrors {
+				reader.skipped = append(reader.skipped, &RowError{Row: count, Errors: []error{err}})
+				continue
+			}
 			return nil, err
 		}
-		mp := make(map[string]interface{})
-		for idx, name := range columns {
-			mp[name] = *(values[idx].(*interface{}))
-		}
 		result = append(result, mp)
 	}
+	if len(result) == 0 {
+		return nil, nil
+	}
 	return result, nil
 }
 
+//逐行读取数据集，rows.Scan的接收参数在各行之间复用
+type rowReader struct {
+	rows       IRows
+	columns    []string
+	values     []interface{}
+	raw        bool        //接收参数为*sql.RawBytes
+	skipErrors bool        //rows.Scan出错时跳过该行
+	skipped    []*RowError //跳过的行及rows.Scan返回的错误
+}
+
+func newRowReader(rows IRows) (*rowReader, error) {
+	columns, err := rowColumns(rows)
+	if nil != err {
+		return nil, err
+	}
+	return &rowReader{rows: rows, columns: columns, values: newScanDest(len(columns))}, nil
+}
+
+//改用*sql.RawBytes接收列值，读取的[]byte引用驱动的缓冲区，下一次rows.Next()之后失效
+func (r *rowReader) useRawBytes() {
+	for idx := range r.values {
+		r.values[idx] = new(sql.RawBytes)
+	}
+	r.raw = true
+}
+
+//读取当前行并写入mp，需在rows.Next()返回true之后调用，mp中原有的数据会被清空，mp为nil时新建
+func (r *rowReader) readInto(mp map[string]interface{}) (map[string]interface{}, error) {
+	if err := r.rows.Scan(r.values...); nil != err {
+		return nil, err
+	}
+	if mp == nil {
+		mp = make(map[string]interface{}, len(r.columns))
+	} else {
+		for key := range mp {
+			delete(mp, key)
+		}
+	}
+	for idx, name := range r.columns {
+		if r.raw {
+			mp[name] = rawValue(*(r.values[idx].(*sql.RawBytes)))
+			continue
+		}
+		mp[name] = *(r.values[idx].(*interface{}))
+	}
+	return mp, nil
+}
+
+//NULL列的sql.RawBytes为nil，转换为nil值以便按NULL处理
+func rawValue(raw sql.RawBytes) interface{} {
+	if raw == nil {
+		return nil
+	}
+	return []byte(raw)
+}
+
+//提取数据集，保留列顺序及每行的原始值
+func ExtraRows(rows IRows) (columns []string, values [][]interface{}, err error) {
+	columns, err = rowColumns(rows)
+	if nil != err {
+		return nil, nil, err
+	}
+	dest := newScanDest(len(columns))
+
+	for rows.Next() {
+		if err = rows.Scan(dest...); nil != err {
+			return nil, nil, err
+		}
+		row := make([]interface{}, len(columns))
+		for idx := range columns {
+			row[idx] = *(dest[idx].(*interface{}))
+		}
+		values = append(values, row)
+	}
+	return columns, values, nil
+}
+
+//读取一行数据，返回各列驱动值的Go类型名称（如"[]uint8"、"int64"、"time.Time"，NULL为"nil"），用于排查类型转换失败
+//该函数会消耗rows中的一行且不关闭rows，之后继续扫描将从下一行开始；没有数据时返回ErrEmptyResult
+func DescribeRow(rows IRows) (map[string]string, error) {
+	reader, err := newRowReader(rows)
+	if nil != err {
+		return nil, err
+	}
+	if !rows.Next() {
+		return nil, ErrEmptyResult
+	}
+	row, err := reader.readInto(nil)
+	if nil != err {
+		return nil, err
+	}
+	types := make(map[string]string, len(row))
+	for column, value := range row {
+		if value == nil {
+			types[column] = "nil"
+			continue
+		}
+		types[column] = fmt.Sprintf("%T", value)
+	}
+	return types, nil
+}
+
 //多结果集处理
-func multiResults(arr []map[string]interface{}, target interface{}) error {
+func (s *Scanner) multiResults(arr []map[string]interface{}, target interface{}) error {
 	valueObj := getPtrObjectValue(target)
 	if !valueObj.CanSet() {
 		return ErrTargetNotSettable
 	}
 
 	length := len(arr)
-	valueSliceObj := reflect.MakeSlice(valueObj.Type(), 0, length)
-	typeObj := valueSliceObj.Type()
+	//未指定结果集的列顺序时按首行构建一次列名索引，避免每行重复构建
+	if s.columns == nil && length > 0 {
+		s = s.withColumns(sortedColumns(arr[0]))
+	}
+	var valueSliceObj reflect.Value
+	reuse := s.ReuseSlice && valueObj.Cap() >= length
+	if reuse {
+		valueSliceObj = valueObj.Slice(0, length)
+	} else {
+		valueSliceObj = reflect.MakeSlice(valueObj.Type(), length, length)
+	}
+	elemType := valueSliceObj.Type().Elem()
+	scalar := isScalarType(elemType)
+	//结构体元素的字段映射信息各行共用
+	var fields []fieldInfo
+	structElem, ptr, isStruct := structType(elemType)
+	if isStruct && !scalar {
+		fields = s.structFields(structElem)
+	}
+	zero := reflect.Zero(elemType)
 	var err error
+	var rowErrors []*RowError
 	for i := 0; i < length; i++ {
-		target := reflect.New(typeObj.Elem())
-		err = singleResult(arr[i], target.Interface())
+		if s.OnRow != nil {
+			if err := s.OnRow(i, arr[i]); err != nil {
+				return err
+			}
+		}
+		//直接写入slice的元素，复用的底层数组先清空该元素
+		elem := valueSliceObj.Index(i)
+		if reuse {
+			elem.Set(zero)
+		}
+		switch {
+		case scalar:
+			err = s.scalarResult(arr[i], elem)
+		case fields != nil && ptr:
+			//[]*Struct的元素为指针时为每行分配新的结构体
+			targetInstance := reflect.New(structElem)
+			err = s.structResult(arr[i], targetInstance.Elem(), fields)
+			if _, partial := err.(fieldErrors); nil == err || partial {
+				elem.Set(targetInstance)
+			}
+		case fields != nil:
+			err = s.structResult(arr[i], elem, fields)
+		default:
+			err = s.singleResult(arr[i], elem.Addr().Interface())
+		}
 		if nil != err {
-			return err
+			//ContinueOnError模式下记录该行错误，保留已转换成功的字段
+			errs, ok := err.(fieldErrors)
+			if !ok {
+				return err
+			}
+			rowErrors = append(rowErrors, &RowError{Row: i, Errors: errs})
 		}
-		valueSliceObj = reflect.Append(valueSliceObj, target.Elem())
 	}
 	valueObj.Set(valueSliceObj)
+	if len(rowErrors) > 0 {
+		return &MultiError{Rows: rowErrors}
+	}
+	return nil
+}
+
+//单列结果处理
+func (s *Scanner) scalarResult(result map[string]interface{}, valueObj reflect.Value) error {
+	if len(result) > 1 {
+		return ErrMultipleColumns
+	}
+	for column, mapValue := range result {
+		if err := s.valueConvert(mapValue, valueObj, ""); err != nil {
+			err = wrapConvertError(column, "", err)
+			if s.ContinueOnError {
+				return fieldErrors{err}
+			}
+			return err
+		}
+	}
 	return nil
 }
 
 //单一结果处理
-func singleResult(result map[string]interface{}, target interface{}) (resp error) {
+func (s *Scanner) singleResult(result map[string]interface{}, target interface{}) (resp error) {
 
 	valueObj := getPtrObjectValue(target)
 	if !valueObj.CanSet() {
@@ -151,37 +681,284 @@ func singleResult(result map[string]interface{}, target interface{}) (resp error
 	//需递归知道获取真实类型位置
 	if kind == reflect.Ptr {
 		targetInstance := reflect.New(typeObj.Elem())
-		err := singleResult(result, targetInstance.Interface())
-		if nil == err {
+		err := s.singleResult(result, targetInstance.Interface())
+		if _, partial := err.(fieldErrors); nil == err || partial {
 			valueObj.Set(targetInstance)
 		}
 		return err
 	}
 
-	for i := 0; i < valueObj.NumField(); i++ {
-		fieldTypeI := typeObj.Field(i)
+	if kind == reflect.Map {
+		return s.mapResult(result, valueObj)
+	}
+
+	if kind != reflect.Struct {
+		return fmt.Errorf("%w: %s", ErrUnSupportTypeConvert, typeObj)
+	}
+
+	return s.structResult(result, valueObj, s.structFields(typeObj))
+}
+
+//按字段映射信息将单行数据写入结构体，赋值完成后调用DefaultSetter
+func (s *Scanner) structResult(result map[string]interface{}, valueObj reflect.Value, fields []fieldInfo) error {
+	_, err := s.assignFields(&columnLookup{result: result, columns: s.columnsOf(result)}, valueObj, fields)
+	if hasExtraField(fields) {
+		s.assignExtra(result, valueObj, fields)
+	}
+	if _, partial := err.(fieldErrors); nil == err || partial {
+		if setter, ok := valueObj.Addr().Interface().(DefaultSetter); ok {
+			setter.SetDefault()
+		}
+	}
+	return err
+}
 
-		valueI := valueObj.Field(i)
-		if !valueI.CanSet() {
+//未匹配到字段的列写入extra字段，没有剩余列时extra字段为nil
+func (s *Scanner) assignExtra(result map[string]interface{}, valueObj reflect.Value, fields []fieldInfo) {
+	matched, _ := s.matchedColumns(result, fields)
+	var extra map[string]interface{}
+	for column, value := range result {
+		if matched[column] {
 			continue
 		}
-		tagName, ok := fieldTypeI.Tag.Lookup(DefaultTagName)
-		if !ok || tagName == "" {
+		if extra == nil {
+			extra = make(map[string]interface{}, len(result)-len(matched))
+		}
+		extra[column] = value
+	}
+	for _, field := range fields {
+		if field.extra {
+			valueObj.Field(field.index).Set(reflect.ValueOf(extra))
+		}
+	}
+}
+
+//单行数据写入键为string的map，每列的值转换为map的值类型
+func (s *Scanner) mapResult(result map[string]interface{}, valueObj reflect.Value) error {
+	typeObj := valueObj.Type()
+	if typeObj.Key().Kind() != reflect.String {
+		return fmt.Errorf("%w: map key %s", ErrUnSupportTypeConvert, typeObj.Key())
+	}
+	mapObj := reflect.MakeMapWithSize(typeObj, len(result))
+	var errs fieldErrors
+	for column, value := range result {
+		elem := reflect.New(typeObj.Elem()).Elem()
+		if err := s.valueConvert(value, elem, ""); err != nil {
+			err = wrapConvertError(column, "", err)
+			if !s.ContinueOnError {
+				return err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		mapObj.SetMapIndex(reflect.ValueOf(column).Convert(typeObj.Key()), elem)
+	}
+	valueObj.Set(mapObj)
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
+
+//按字段映射信息为结构体赋值，notNull表示是否有字段匹配到了非NULL的列值
+//ContinueOnError模式下跳过转换失败的字段，以fieldErrors返回收集到的全部错误
+func (s *Scanner) assignFields(lookup *columnLookup, valueObj reflect.Value, fields []fieldInfo) (notNull bool, err error) {
+	var errs fieldErrors
+	for _, field := range fields {
+		if field.extra {
 			continue
 		}
-		mapValue, ok := result[tagName]
+		valueI := valueObj.Field(field.index)
+		if field.nested != nil {
+			nestedNotNull, err := s.assignNested(lookup, valueI, field)
+			notNull = notNull || nestedNotNull
+			if err != nil {
+				if !s.ContinueOnError {
+					return notNull, err
+				}
+				errs = append(errs, err.(fieldErrors)...)
+			}
+			continue
+		}
+		if !s.selected(field) {
+			continue
+		}
+		mapValue, column, ok := lookup.getField(field)
+		//default选项的值不计入，LEFT JOIN未关联到数据时子结构体指针保持nil
+		notNull = notNull || (ok && mapValue != nil && !s.isNullLiteral(mapValue))
+		//列为NULL或不存在时按[]byte源值转换default选项的值
+		if defaultVal, exist := field.opts.value(TagOptionDefault); exist && (mapValue == nil || s.isNullLiteral(mapValue)) {
+			mapValue, ok = []byte(defaultVal), true
+		}
 		if !ok {
 			continue
 		}
-		err := valueConvert(mapValue, valueI)
+		if s.stats != nil {
+			s.stats.Conversions++
+		}
+		var err error
+		if fn, exist := s.columnConverter(column, field); exist {
+			err = s.handleColumnConvert(fn, mapValue, valueI, field.traits)
+		} else {
+			err = s.convertValue(mapValue, valueI, field.opts, field.traits)
+		}
+		if transform, exist := field.opts.value(TagOptionTransform); exist && err == nil {
+			err = applyTransform(transform, valueI)
+		}
 		if err != nil {
-			return err
+			err = wrapConvertError(field.column, field.name, err)
+			if !s.ContinueOnError {
+				return notNull, err
+			}
+			errs = append(errs, err)
 		}
 	}
+	if len(errs) > 0 {
+		return notNull, errs
+	}
+	return notNull, nil
+}
+
+//按实际匹配的列名查找ColumnConverters中的转换函数，其次按字段标签的列名查找
+func (s *Scanner) columnConverter(column string, field fieldInfo) (ConverterFunc, bool) {
+	if len(s.ColumnConverters) == 0 {
+		return nil, false
+	}
+	if fn, ok := s.ColumnConverters[column]; ok {
+		return fn, true
+	}
+	if field.mapped {
+		return nil, false
+	}
+	fn, ok := s.ColumnConverters[field.column]
+	return fn, ok
+}
+
+//使用ColumnConverters中的转换函数设值，NULL按内置规则处理，不调用转换函数
+func (s *Scanner) handleColumnConvert(fn ConverterFunc, sourceVal interface{}, rTargetVal reflect.Value, traits typeTraits) error {
+	if nil == sourceVal || s.isNullLiteral(sourceVal) {
+		return handleConvertNull(rTargetVal, traits)
+	}
+	return handleCustomConvert(fn, sourceVal, rTargetVal)
+}
+
+//子结构体赋值，结构体指针仅在有字段匹配到非NULL的列值时才分配
+func (s *Scanner) assignNested(lookup *columnLookup, valueI reflect.Value, field fieldInfo) (bool, error) {
+	if !field.ptr {
+		return s.assignFields(lookup, valueI, field.nested)
+	}
+	if !valueI.IsNil() {
+		return s.assignFields(lookup, valueI.Elem(), field.nested)
+	}
+	targetInstance := reflect.New(valueI.Type().Elem())
+	notNull, err := s.assignFields(lookup, targetInstance.Elem(), field.nested)
+	if notNull && (err == nil || s.ContinueOnError) {
+		valueI.Set(targetInstance)
+	}
+	return notNull, err
+}
+
+//设置有符号整数，超出字段类型范围时返回ErrValueOverflow
+func setInt(rTargetVal reflect.Value, intVal int64) error {
+	if rTargetVal.OverflowInt(intVal) {
+		return overflowError(intVal, rTargetVal)
+	}
+	rTargetVal.SetInt(intVal)
 	return nil
 }
 
-//直接设置
+//设置无符号整数，超出字段类型范围时返回ErrValueOverflow
+func setUint(rTargetVal reflect.Value, uintVal uint64) error {
+	if rTargetVal.OverflowUint(uintVal) {
+		return overflowError(uintVal, rTargetVal)
+	}
+	rTargetVal.SetUint(uintVal)
+	return nil
+}
+
+func overflowError(val interface{}, rTargetVal reflect.Value) error {
+	return fmt.Errorf("%w: %v -> %s", ErrValueOverflow, val, rTargetVal.Type())
+}
+
+//有符号整数源值转换
+func handleConvertInt(intVal int64, rTargetVal reflect.Value) error {
+	switch kind := rTargetVal.Kind(); {
+	case isSignedInteger(kind):
+		return setInt(rTargetVal, intVal)
+	case isUnsignedInteger(kind):
+		if intVal < 0 {
+			return overflowError(intVal, rTargetVal)
+		}
+		return setUint(rTargetVal, uint64(intVal))
+	case isFloat(kind):
+		rTargetVal.SetFloat(float64(intVal))
+	case kind == reflect.Bool:
+		rTargetVal.SetBool(intVal != 0)
+	case kind == reflect.String:
+		rTargetVal.SetString(strconv.FormatInt(intVal, 10))
+	default:
+		return ErrConvertValue
+	}
+	return nil
+}
+
+//无符号整数源值转换
+func handleConvertUint(uintVal uint64, rTargetVal reflect.Value) error {
+	switch kind := rTargetVal.Kind(); {
+	case isUnsignedInteger(kind):
+		return setUint(rTargetVal, uintVal)
+	case isSignedInteger(kind):
+		if uintVal > math.MaxInt64 {
+			return overflowError(uintVal, rTargetVal)
+		}
+		return setInt(rTargetVal, int64(uintVal))
+	case isFloat(kind):
+		rTargetVal.SetFloat(float64(uintVal))
+	case kind == reflect.Bool:
+		rTargetVal.SetBool(uintVal != 0)
+	case kind == reflect.String:
+		rTargetVal.SetString(strconv.FormatUint(uintVal, 10))
+	default:
+		return ErrConvertValue
+	}
+	return nil
+}
+
+//浮点数源值转换，整数字段截断小数部分，超出字段类型范围时返回ErrValueOverflow
+func handleConvertFloat(floatVal float64, bitSize int, rTargetVal reflect.Value) error {
+	switch kind := rTargetVal.Kind(); {
+	case isFloat(kind):
+		rTargetVal.SetFloat(floatVal)
+	case isSignedInteger(kind):
+		truncated := math.Trunc(floatVal)
+		//float64(math.MaxInt64)为2^63，已超出int64范围
+		if math.IsNaN(truncated) || truncated < math.MinInt64 || truncated >= math.MaxInt64 {
+			return overflowError(floatVal, rTargetVal)
+		}
+		return setInt(rTargetVal, int64(truncated))
+	case isUnsignedInteger(kind):
+		truncated := math.Trunc(floatVal)
+		if math.IsNaN(truncated) || truncated < 0 || truncated >= math.MaxUint64 {
+			return overflowError(floatVal, rTargetVal)
+		}
+		return setUint(rTargetVal, uint64(truncated))
+	case kind == reflect.String:
+		rTargetVal.SetString(strconv.FormatFloat(floatVal, 'f', -1, bitSize))
+	default:
+		return ErrConvertValue
+	}
+	return nil
+}
+
+//包装转换错误，附带出错的列名及字段名，保留原始错误供errors.Is判断
+func wrapConvertError(column, field string, err error) error {
+	if field == "" {
+		return fmt.Errorf("db_scan: column %q: %w", column, err)
+	}
+	return fmt.Errorf("db_scan: column %q -> field %s: %w", column, field, err)
+}
+
+//直接设置，仅处理源值可直接赋值的情况；命名类型（如type Status string）不可由string赋值，按Kind转换
 func directSet(sourceVal interface{}, rTargetVal reflect.Value) bool {
 	sourceType := reflect.TypeOf(sourceVal)
 	if nil == sourceType {
@@ -197,88 +974,471 @@ func directSet(sourceVal interface{}, rTargetVal reflect.Value) bool {
 	return false
 }
 
+//获取字段对应的sql.Scanner，字段可寻址时优先使用其指针，traits为字段类型实现的转换接口
+func asSqlScanner(rTargetVal reflect.Value, traits typeTraits) (sql.Scanner, bool) {
+	if traits&traitSqlScanner == 0 {
+		return nil, false
+	}
+	if rTargetVal.CanAddr() {
+		if scanner, ok := rTargetVal.Addr().Interface().(sql.Scanner); ok {
+			return scanner, true
+		}
+	}
+	//空指针无法调用Scan
+	if rTargetVal.Kind() == reflect.Ptr && rTargetVal.IsNil() {
+		return nil, false
+	}
+	scanner, ok := rTargetVal.Interface().(sql.Scanner)
+	return scanner, ok
+}
+
 //map自动数据格式转换
-func valueConvert(sourceVal interface{}, rTargetVal reflect.Value) error {
+//opts为字段标签中的选项
+func (s *Scanner) valueConvert(sourceVal interface{}, rTargetVal reflect.Value, opts tagOptions) error {
+	return s.convertValue(sourceVal, rTargetVal, opts, typeTraitsOf(rTargetVal.Type()))
+}
+
+//按目标类型实现的转换接口进行值转换，结构体字段的traits在解析字段时已确定
+func (s *Scanner) convertValue(sourceVal interface{}, rTargetVal reflect.Value, opts tagOptions, traits typeTraits) error {
+
+	if fn, ok := lookupConverter(rTargetVal.Type()); ok {
+		return handleCustomConvert(fn, sourceVal, rTargetVal)
+	}
+
+	if unmarshaler, ok := asColumnUnmarshaler(rTargetVal, traits); ok {
+		return unmarshaler.UnmarshalColumn(sourceVal)
+	}
+
+	//定长CHAR(n)列去掉末尾填充的空格，*string字段也需在NULL判断前去掉，"NULL  "才能识别为NULL
+	if s.TrimSpace && derefType(rTargetVal.Type()).Kind() == reflect.String {
+		if text, ok := textValue(sourceVal); ok {
+			sourceVal = strings.TrimRight(text, " ")
+		}
+	}
 
 	sourceType := reflect.TypeOf(sourceVal)
-	if nil == sourceType {
-		return nil
+	if nil == sourceType || s.isNullLiteral(sourceVal) {
+		return handleConvertNull(rTargetVal, traits)
 	}
 	targetType := rTargetVal.Type()
 
+	//指针字段：非NULL时分配新值并转换到其指向的对象
+	if targetType.Kind() == reflect.Ptr {
+		return s.handleConvertPtr(sourceVal, rTargetVal, opts)
+	}
+
+	if s.TreatEmptyAsZero && isEmptyText(sourceVal) && (isNumeric(targetType.Kind()) || targetType.Kind() == reflect.Bool) {
+		rTargetVal.Set(reflect.Zero(targetType))
+		return nil
+	}
+
+	if handled, err := handleConvertEnum(sourceVal, rTargetVal); handled {
+		return err
+	}
+
+	//sql.Scanner优先于内置转换，decimal等高精度类型直接接收原始值，避免经由float64损失精度
+	if scanner, ok := asSqlScanner(rTargetVal, traits); ok {
+		return scanner.Scan(sourceVal)
+	}
+
+	//高精度NUMERIC按文本解析，避免经由int64/float64溢出或损失精度
+	if handled, err := handleConvertBig(sourceVal, rTargetVal); handled {
+		return err
+	}
+
+	if s.HexDecodeBytes && isBytesType(targetType) {
+		if handled, err := handleDecodeHex(sourceVal, rTargetVal); handled {
+			return err
+		}
+	}
+
+	//net.IP等底层为[]byte，需在直接赋值前按文本解析
+	if handled, err := handleConvertNet(sourceVal, rTargetVal); handled {
+		return err
+	}
+
+	if bytesVal, ok := sourceVal.([]byte); ok && s.BytesToString && isEmptyInterface(targetType) {
+		rTargetVal.Set(reflect.ValueOf(string(bytesVal)))
+		return nil
+	}
+
 	if directSet(sourceVal, rTargetVal) {
 		return nil
 	}
 
 	switch assertT := sourceVal.(type) {
 	case time.Time:
-		return handleConvertTime(assertT, sourceType, &rTargetVal)
+		return s.handleConvertTime(assertT, &rTargetVal, opts)
 	}
 
 	switch sourceType.Kind() {
 	case reflect.Slice:
-		return handleConvertMapSliceToField(sourceVal, &rTargetVal)
-	case reflect.Int64:
-		if isSignedInteger(targetType.Kind()) {
-			rTargetVal.SetInt(sourceVal.(int64))
-		} else if isUnsignedInteger(targetType.Kind()) {
-			rTargetVal.SetUint(uint64(sourceVal.(int64)))
+		return s.handleConvertMapSliceToField(sourceVal, &rTargetVal, opts)
+	case reflect.String:
+		//包括json.Number等底层为string的类型，按文本解析，与Int64()/Float64()的规则一致
+		return s.handleConvertString(reflect.ValueOf(sourceVal).String(), &rTargetVal, opts)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return handleConvertInt(reflect.ValueOf(sourceVal).Int(), rTargetVal)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return handleConvertUint(reflect.ValueOf(sourceVal).Uint(), rTargetVal)
+	case reflect.Bool:
+		if targetType.Kind() != reflect.Bool {
+			return ErrConvertValue
 		}
+		rTargetVal.SetBool(reflect.ValueOf(sourceVal).Bool())
 	case reflect.Float32:
-		if isFloat(targetType.Kind()) {
-			rTargetVal.SetFloat(float64(sourceVal.(float32)))
-		}
+		return handleConvertFloat(reflect.ValueOf(sourceVal).Float(), 32, rTargetVal)
 	case reflect.Float64:
-		if isFloat(targetType.Kind()) {
-			rTargetVal.SetFloat(sourceVal.(float64))
-		}
+		return handleConvertFloat(reflect.ValueOf(sourceVal).Float(), 64, rTargetVal)
 	default:
+		//其余数值类型（如complex）按Go的类型转换规则处理
+		if isNumeric(sourceType.Kind()) && isNumeric(targetType.Kind()) && sourceType.ConvertibleTo(targetType) {
+			rTargetVal.Set(reflect.ValueOf(sourceVal).Convert(targetType))
+			return nil
+		}
 		return ErrConvertValue
 	}
 	return nil
 }
 
-//slice的值转换
-func handleConvertMapSliceToField(mapValue interface{}, rTargetValPtr *reflect.Value) error {
-	rTargetValKind := (*rTargetValPtr).Type().Kind()
+//NULL值处理：指针字段置为nil，sql.Scanner字段调用Scan(nil)，其余字段置为零值
+func handleConvertNull(rTargetVal reflect.Value, traits typeTraits) error {
+	if rTargetVal.Kind() == reflect.Ptr {
+		rTargetVal.Set(reflect.Zero(rTargetVal.Type()))
+		return nil
+	}
+	if scanner, ok := asSqlScanner(rTargetVal, traits); ok {
+		return scanner.Scan(nil)
+	}
+	rTargetVal.Set(reflect.Zero(rTargetVal.Type()))
+	return nil
+}
 
-	mapValueSlice, ok := mapValue.([]byte)
-	if !ok {
+//指针字段的值转换，NULL已在valueConvert中置为nil，此处仅在转换成功后才分配并设置指针
+func (s *Scanner) handleConvertPtr(sourceVal interface{}, rTargetVal reflect.Value, opts tagOptions) error {
+	targetInstance := reflect.New(rTargetVal.Type().Elem())
+	if err := s.valueConvert(sourceVal, targetInstance.Elem(), opts); err != nil {
+		return err
+	}
+	rTargetVal.Set(targetInstance)
+	return nil
+}
+
+//slice的值转换
+func (s *Scanner) handleConvertMapSliceToField(mapValue interface{}, rTargetValPtr *reflect.Value, opts tagOptions) error {
+	sourceObj := reflect.ValueOf(mapValue)
+	if sourceObj.Type().Elem().Kind() != reflect.Uint8 {
 		return ErrSliceToString
 	}
-	mapValueStr := string(mapValueSlice)
+	mapValueSlice := sourceObj.Bytes()
+	//二进制数据直接拷贝到字节slice字段，不经过字符串转换
+	if isBytesType((*rTargetValPtr).Type()) {
+		rTargetValPtr.SetBytes(append([]byte(nil), mapValueSlice...))
+		return nil
+	}
+	//定长字节数组（如UUID的[16]byte）要求长度完全一致
+	if isByteArrayType((*rTargetValPtr).Type()) {
+		if len(mapValueSlice) != rTargetValPtr.Len() {
+			return fmt.Errorf("%w: %d bytes -> %s", ErrConvertValue, len(mapValueSlice), rTargetValPtr.Type())
+		}
+		reflect.Copy(*rTargetValPtr, sourceObj)
+		return nil
+	}
+	if isJSONType((*rTargetValPtr).Type()) {
+		return handleUnmarshalJSON(mapValueSlice, rTargetValPtr)
+	}
+	err := s.convertStringTo(string(mapValueSlice), *rTargetValPtr, opts)
+	if err == ErrUnSupportTypeConvert {
+		return fmt.Errorf("%w: []byte(len=%d) -> %s(kind=%s)", ErrUnSupportTypeConvert, len(mapValueSlice), rTargetValPtr.Type(), rTargetValPtr.Kind())
+	}
+	return err
+}
+
+//string的值转换
+func (s *Scanner) handleConvertString(str string, rTargetValPtr *reflect.Value, opts tagOptions) error {
+	switch {
+	case isJSONType((*rTargetValPtr).Type()):
+		return handleUnmarshalJSON([]byte(str), rTargetValPtr)
+	case isBytesType((*rTargetValPtr).Type()):
+		//json.RawMessage等字节slice字段原样保存文本
+		rTargetValPtr.SetBytes([]byte(str))
+		return nil
+	}
+	err := s.convertStringTo(str, *rTargetValPtr, opts)
+	//string源值不支持的字段类型沿用ErrConvertValue
+	if err == ErrUnSupportTypeConvert {
+		return ErrConvertValue
+	}
+	return err
+}
+
+//文本解析到时间、时长、字符串、整数、浮点数或bool字段，[]byte与string源值共用
+//整数按base选项指定的进制解析，默认十进制；其余类型的字段返回ErrUnSupportTypeConvert
+func (s *Scanner) convertStringTo(str string, target reflect.Value, opts tagOptions) error {
+	kind := target.Kind()
 	switch {
-	case rTargetValKind == reflect.String:
-		rTargetValPtr.SetString(mapValueStr)
-	case isSignedInteger(rTargetValKind):
-		intVal, err := strconv.ParseInt(mapValueStr, 10, 64)
+	case target.Type() == timeType:
+		return s.handleParseTime(str, &target)
+	case target.Type() == durationType:
+		return handleParseDuration(str, &target)
+	case kind == reflect.String:
+		target.SetString(str)
+	case isSignedInteger(kind):
+		base, err := intBase(opts)
 		if nil != err {
-			return ErrConvertValue
+			return err
 		}
-		rTargetValPtr.SetInt(intVal)
-	case isUnsignedInteger(rTargetValKind):
-		uintVal, err := strconv.ParseUint(mapValueStr, 10, 64)
+		intVal, err := strconv.ParseInt(trimBasePrefix(str, base), base, 64)
 		if nil != err {
-			return ErrConvertValue
+			return convertError(err)
 		}
-		rTargetValPtr.SetUint(uintVal)
-	case isFloat(rTargetValKind):
-		floatVal, err := strconv.ParseFloat(mapValueStr, 64)
+		return setInt(target, intVal)
+	case isUnsignedInteger(kind):
+		base, err := intBase(opts)
 		if nil != err {
-			return ErrConvertValue
+			return err
 		}
-		rTargetValPtr.SetFloat(floatVal)
+		uintVal, err := strconv.ParseUint(trimBasePrefix(str, base), base, 64)
+		if nil != err {
+			return convertError(err)
+		}
+		return setUint(target, uintVal)
+	case isFloat(kind):
+		floatVal, err := strconv.ParseFloat(str, 64)
+		if nil != err {
+			return convertError(err)
+		}
+		target.SetFloat(floatVal)
+	case kind == reflect.Bool:
+		boolVal, err := strconv.ParseBool(str)
+		if nil != err {
+			return convertError(err)
+		}
+		target.SetBool(boolVal)
 	default:
 		return ErrUnSupportTypeConvert
 	}
 	return nil
 }
 
-func handleConvertTime(assertT time.Time, mvt reflect.Type, valueI *reflect.Value) error {
-	if (*valueI).Type().Kind() == reflect.String {
-		str := assertT.Format(DefaultTimeFormat)
+//去掉与进制一致的前缀，如base=16时的"0xFF"，符号保留在前缀之前
+func trimBasePrefix(str string, base int) string {
+	var prefix string
+	switch base {
+	case 16:
+		prefix = "0x"
+	case 8:
+		prefix = "0o"
+	case 2:
+		prefix = "0b"
+	default:
+		return str
+	}
+	sign := ""
+	if str != "" && (str[0] == '+' || str[0] == '-') {
+		sign, str = str[:1], str[1:]
+	}
+	if len(str) > len(prefix) && strings.EqualFold(str[:len(prefix)], prefix) {
+		str = str[len(prefix):]
+	}
+	return sign + str
+}
+
+//获取base选项指定的整数进制，未设置时为10，0表示按前缀自动识别
+func intBase(opts tagOptions) (int, error) {
+	value, ok := opts.value(TagOptionBase)
+	if !ok {
+		return 10, nil
+	}
+	base, err := strconv.Atoi(value)
+	if nil != err || base == 1 || base < 0 || base > 36 {
+		return 0, fmt.Errorf("%w: invalid base %q", ErrConvertValue, value)
+	}
+	return base, nil
+}
+
+//是否为字节slice类型
+func isBytesType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+//是否为定长字节数组类型
+func isByteArrayType(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8
+}
+
+//是否为按JSON解析的字段类型：结构体（time.Time除外）、map及非字节slice
+func isJSONType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		return t != timeType
+	case reflect.Map:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+//数值解析到big.Int、big.Float（或其指针）字段，其余字段返回handled为false
+//big.Float的精度按文本长度确定，保证不低于float64
+func handleConvertBig(sourceVal interface{}, rTargetVal reflect.Value) (handled bool, err error) {
+	targetType := rTargetVal.Type()
+	if targetType != bigIntType && targetType != bigFloatType {
+		return false, nil
+	}
+	target := reflect.New(targetType)
+	if err := parseBig(sourceVal, target.Interface()); err != nil {
+		return true, err
+	}
+	rTargetVal.Set(target.Elem())
+	return true, nil
+}
+
+//按源值类型设置*big.Int或*big.Float，浮点源值只能转换到big.Float
+func parseBig(sourceVal interface{}, target interface{}) error {
+	sourceObj := reflect.ValueOf(sourceVal)
+	var str string
+	switch v := sourceVal.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		switch {
+		case isSignedInteger(sourceObj.Kind()):
+			str = strconv.FormatInt(sourceObj.Int(), 10)
+		case isUnsignedInteger(sourceObj.Kind()):
+			str = strconv.FormatUint(sourceObj.Uint(), 10)
+		case isFloat(sourceObj.Kind()):
+			if f, ok := target.(*big.Float); ok {
+				f.SetFloat64(sourceObj.Float())
+				return nil
+			}
+			return ErrConvertValue
+		default:
+			return ErrConvertValue
+		}
+	}
+	switch t := target.(type) {
+	case *big.Int:
+		if _, ok := t.SetString(str, 10); !ok {
+			return fmt.Errorf("%w: invalid integer %q", ErrConvertValue, str)
+		}
+	case *big.Float:
+		prec := uint(len(str)) * 4
+		if prec < 64 {
+			prec = 64
+		}
+		if _, ok := t.SetPrec(prec).SetString(str); !ok {
+			return fmt.Errorf("%w: invalid decimal %q", ErrConvertValue, str)
+		}
+	}
+	return nil
+}
+
+//以"\x"开头的十六进制文本（如PostgreSQL以hex格式输出的BYTEA）解码后写入字节slice字段，其余值返回handled为false
+func handleDecodeHex(sourceVal interface{}, rTargetVal reflect.Value) (handled bool, err error) {
+	text, ok := textValue(sourceVal)
+	if !ok || !strings.HasPrefix(text, `\x`) {
+		return false, nil
+	}
+	data, err := hex.DecodeString(text[2:])
+	if nil != err {
+		return true, convertError(err)
+	}
+	rTargetVal.SetBytes(data)
+	return true, nil
+}
+
+//INET/MACADDR列的文本解析到net.IP、net.HardwareAddr字段，其余字段返回handled为false
+func handleConvertNet(sourceVal interface{}, rTargetVal reflect.Value) (handled bool, err error) {
+	targetType := rTargetVal.Type()
+	if targetType != ipType && targetType != macType {
+		return false, nil
+	}
+	var str string
+	switch v := sourceVal.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return true, ErrConvertValue
+	}
+	if targetType == ipType {
+		ip := net.ParseIP(str)
+		if ip == nil {
+			return true, fmt.Errorf("%w: invalid ip %q", ErrConvertValue, str)
+		}
+		rTargetVal.Set(reflect.ValueOf(ip))
+		return true, nil
+	}
+	mac, err := net.ParseMAC(str)
+	if nil != err {
+		return true, convertError(err)
+	}
+	rTargetVal.Set(reflect.ValueOf(mac))
+	return true, nil
+}
+
+//JSON/JSONB列解析到结构体、map或slice字段
+func handleUnmarshalJSON(data []byte, rTargetValPtr *reflect.Value) error {
+	targetInstance := reflect.New((*rTargetValPtr).Type())
+	if err := json.Unmarshal(data, targetInstance.Interface()); err != nil {
+		return convertError(err)
+	}
+	rTargetValPtr.Set(targetInstance.Elem())
+	return nil
+}
+
+//时间值转换：字符串字段按时间格式输出，整数字段为Unix秒（unixnano选项为纳秒），浮点字段为带小数的秒
+func (s *Scanner) handleConvertTime(assertT time.Time, valueI *reflect.Value, opts tagOptions) error {
+	kind := (*valueI).Type().Kind()
+	switch {
+	case kind == reflect.String:
+		str := assertT.Format(s.timeFormats()[0])
 		valueI.SetString(str)
 		return nil
+	case isSignedInteger(kind):
+		if opts.has(TagOptionUnixNano) {
+			return setInt(*valueI, assertT.UnixNano())
+		}
+		return setInt(*valueI, assertT.Unix())
+	case isFloat(kind):
+		valueI.SetFloat(float64(assertT.UnixNano()) / float64(time.Second))
+		return nil
+	}
+	return ErrConvertValue
+}
+
+//解析时长，支持time.ParseDuration格式（如"1h30m"）及纳秒整数
+func handleParseDuration(str string, valueI *reflect.Value) error {
+	d, err := time.ParseDuration(str)
+	if nil != err {
+		nanos, parseErr := strconv.ParseInt(str, 10, 64)
+		if nil != parseErr {
+			return convertError(err)
+		}
+		d = time.Duration(nanos)
+	}
+	valueI.SetInt(int64(d))
+	return nil
+}
+
+//按配置的时间格式依次尝试解析时间字符串
+func (s *Scanner) handleParseTime(str string, valueI *reflect.Value) error {
+	for _, layout := range s.timeFormats() {
+		t, err := time.Parse(layout, str)
+		if nil == err {
+			valueI.Set(reflect.ValueOf(t))
+			return nil
+		}
+	}
+	//TIME列只有时间部分，可带小数秒，如"15:04:05.123"
+	if t, err := time.Parse(DefaultClockFormat, str); nil == err {
+		valueI.Set(reflect.ValueOf(t))
+		return nil
 	}
 	return ErrConvertValue
 }
@@ -1,8 +1,18 @@
 package db_scan
 
 import (
-	. "github.com/tevid/gohamcrest"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"net"
+	"strings"
 	"testing"
+	"time"
+
+	. "github.com/tevid/gohamcrest"
 )
 
 func TestDBScanExtraSigleData(t *testing.T) {
@@ -21,7 +31,7 @@ func TestDBScanExtraSigleData(t *testing.T) {
 		"page":  age,
 	}
 
-	err := singleResult(mp, &p)
+	err := defaultScanner.singleResult(mp, &p)
 
 	Assert(t, err, NilVal())
 
@@ -42,7 +52,7 @@ func TestDBScanExtraSigleDataUsingByteString(t *testing.T) {
 		"name": name,
 		"ag":   age,
 	}
-	err := singleResult(mp, &p)
+	err := defaultScanner.singleResult(mp, &p)
 	Assert(t, err, NilVal())
 
 	Assert(t, string(name), Equal(p.Name))
@@ -60,7 +70,7 @@ func TestDBScanExtraMultiData(t *testing.T) {
 	for _, v := range testCases {
 		data = append(data, map[string]interface{}{"age": v})
 	}
-	err := multiResults(data, &students)
+	err := defaultScanner.multiResults(data, &students)
 
 	if err != nil {
 		t.Fail()
@@ -76,3 +86,1065 @@ func TestDBScanExtraMultiData(t *testing.T) {
 		}
 	}
 }
+
+//自定义的Scanner类型
+type upperString struct {
+	val string
+}
+
+func (u *upperString) Scan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return ErrConvertValue
+	}
+	u.val = strings.ToUpper(string(b))
+	return nil
+}
+
+func TestDBScanSqlScanner(t *testing.T) {
+	type Person struct {
+		Name  sql.NullString `pg:"name"`
+		Age   sql.NullInt64  `pg:"age"`
+		Code  upperString    `pg:"code"`
+		Alias *upperString   `pg:"alias"`
+	}
+	p := Person{Alias: &upperString{}}
+	var mp = map[string]interface{}{
+		"name":  []byte("tencent"),
+		"age":   int64(20),
+		"code":  []byte("abc"),
+		"alias": []byte("xyz"),
+	}
+	err := defaultScanner.singleResult(mp, &p)
+	Assert(t, err, NilVal())
+	Assert(t, p.Name, Equal(sql.NullString{String: "tencent", Valid: true}))
+	Assert(t, p.Age, Equal(sql.NullInt64{Int64: 20, Valid: true}))
+	Assert(t, p.Code.val, Equal("ABC"))
+	Assert(t, p.Alias.val, Equal("XYZ"))
+}
+
+func TestDBScanNullValue(t *testing.T) {
+	type Person struct {
+		Age     int           `pg:"age"`
+		AgePtr  *int          `pg:"age"`
+		AgeNull sql.NullInt64 `pg:"age"`
+	}
+	age := 10
+	p := Person{
+		Age:     age,
+		AgePtr:  &age,
+		AgeNull: sql.NullInt64{Int64: 10, Valid: true},
+	}
+	var mp = map[string]interface{}{
+		"age": nil,
+	}
+	err := defaultScanner.singleResult(mp, &p)
+	Assert(t, err, NilVal())
+	Assert(t, p.Age, Equal(0))
+	Assert(t, p.AgePtr == nil, Equal(true))
+	Assert(t, p.AgeNull, Equal(sql.NullInt64{}))
+}
+
+func TestDBScanPtrField(t *testing.T) {
+	type Person struct {
+		Age      *int       `pg:"age"`
+		Name     *string    `pg:"name"`
+		Birthday *time.Time `pg:"birthday"`
+		Score    *float64   `pg:"score"`
+		Nick     *string    `pg:"nick"`
+	}
+	birthday := time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)
+	var p Person
+	var mp = map[string]interface{}{
+		"age":      int64(20),
+		"name":     []byte("tencent"),
+		"birthday": birthday,
+		"score":    float64(99.5),
+		"nick":     nil,
+	}
+	err := defaultScanner.singleResult(mp, &p)
+	Assert(t, err, NilVal())
+	Assert(t, *p.Age, Equal(20))
+	Assert(t, *p.Name, Equal("tencent"))
+	Assert(t, *p.Birthday, Equal(birthday))
+	Assert(t, *p.Score, Equal(99.5))
+	Assert(t, p.Nick == nil, Equal(true))
+}
+
+func TestDBScanBool(t *testing.T) {
+	type Flag bool
+	type Setting struct {
+		Enabled Flag `pg:"enabled"`
+	}
+	testCases := []struct {
+		source interface{}
+		expect Flag
+	}{
+		{true, true},
+		{false, false},
+		{int64(1), true},
+		{int64(0), false},
+		{[]byte("t"), true},
+		{[]byte("f"), false},
+		{[]byte("true"), true},
+		{[]byte("false"), false},
+		{[]byte("1"), true},
+		{[]byte("0"), false},
+		{"true", true},
+		{"false", false},
+		{"1", true},
+		{"0", false},
+	}
+	for _, tc := range testCases {
+		s := Setting{Enabled: !tc.expect}
+		err := defaultScanner.singleResult(map[string]interface{}{"enabled": tc.source}, &s)
+		Assert(t, err, NilVal())
+		Assert(t, s.Enabled, Equal(tc.expect))
+	}
+
+	var s Setting
+	err := defaultScanner.singleResult(map[string]interface{}{"enabled": []byte("yes")}, &s)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	err = defaultScanner.singleResult(map[string]interface{}{"enabled": "yes"}, &s)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	//bool源值只能写入bool字段
+	var r struct {
+		Name  string  `pg:"name"`
+		Count int     `pg:"count"`
+		Ratio float64 `pg:"ratio"`
+	}
+	for _, column := range []string{"name", "count", "ratio"} {
+		err = defaultScanner.singleResult(map[string]interface{}{column: true}, &r)
+		Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	}
+}
+
+func TestDBScanStringSource(t *testing.T) {
+	type Stat struct {
+		Count   int     `pg:"count"`
+		Total   uint64  `pg:"total"`
+		Ratio   float64 `pg:"ratio"`
+		Enabled bool    `pg:"enabled"`
+		Name    string  `pg:"name"`
+	}
+	mp := map[string]interface{}{"count": "42", "total": "7", "ratio": "0.25", "enabled": "true", "name": "tc"}
+	var st Stat
+	err := defaultScanner.singleResult(mp, &st)
+	Assert(t, err, NilVal())
+	Assert(t, st, Equal(Stat{Count: 42, Total: 7, Ratio: 0.25, Enabled: true, Name: "tc"}))
+}
+
+func TestDBScanStringBytesParity(t *testing.T) {
+	type Record struct {
+		Count     int           `pg:"count"`
+		Small     int8          `pg:"small"`
+		Total     uint32        `pg:"total"`
+		Ratio     float32       `pg:"ratio"`
+		Enabled   bool          `pg:"enabled"`
+		Name      string        `pg:"name"`
+		CreatedAt time.Time     `pg:"created_at"`
+		Timeout   time.Duration `pg:"timeout"`
+	}
+	texts := map[string]string{
+		"count":      "-42",
+		"total":      "7",
+		"ratio":      "0.5",
+		"enabled":    "false",
+		"name":       "tc",
+		"created_at": "2020-05-06 07:08:09",
+		"timeout":    "1m30s",
+	}
+	bytesRow := make(map[string]interface{}, len(texts))
+	stringRow := make(map[string]interface{}, len(texts))
+	for column, text := range texts {
+		bytesRow[column] = []byte(text)
+		stringRow[column] = text
+	}
+	var fromBytes, fromString Record
+	Assert(t, defaultScanner.singleResult(bytesRow, &fromBytes), NilVal())
+	Assert(t, defaultScanner.singleResult(stringRow, &fromString), NilVal())
+	Assert(t, fromString, Equal(fromBytes))
+	Assert(t, fromString.Count, Equal(-42))
+	Assert(t, fromString.Timeout, Equal(90*time.Second))
+
+	for _, text := range []string{"128", "x"} {
+		var r Record
+		errBytes := defaultScanner.singleResult(map[string]interface{}{"small": []byte(text)}, &r)
+		errString := defaultScanner.singleResult(map[string]interface{}{"small": text}, &r)
+		Assert(t, errBytes, Not(NilVal()))
+		Assert(t, errString.Error(), Equal(errBytes.Error()))
+	}
+}
+
+func TestDBScanParseTime(t *testing.T) {
+	type Event struct {
+		CreatedAt time.Time `pg:"created_at"`
+	}
+	testCases := []struct {
+		source interface{}
+		expect time.Time
+	}{
+		{[]byte("2020-05-06 07:08:09"), time.Date(2020, 5, 6, 7, 8, 9, 0, time.UTC)},
+		{"2020-05-06 07:08:09", time.Date(2020, 5, 6, 7, 8, 9, 0, time.UTC)},
+		{[]byte("2020-05-06T07:08:09Z"), time.Date(2020, 5, 6, 7, 8, 9, 0, time.UTC)},
+		{"2020-05-06T07:08:09+08:00", time.Date(2020, 5, 5, 23, 8, 9, 0, time.UTC)},
+		{[]byte("2020-05-06"), time.Date(2020, 5, 6, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		var e Event
+		err := defaultScanner.singleResult(map[string]interface{}{"created_at": tc.source}, &e)
+		Assert(t, err, NilVal())
+		Assert(t, e.CreatedAt.Equal(tc.expect), Equal(true))
+	}
+
+	var e Event
+	err := defaultScanner.singleResult(map[string]interface{}{"created_at": []byte("06/05/2020")}, &e)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+func TestDBScanTimeOfDay(t *testing.T) {
+	type Shift struct {
+		StartAt time.Time  `pg:"start_at"`
+		EndAt   *time.Time `pg:"end_at"`
+	}
+	mp := map[string]interface{}{"start_at": []byte("09:30:00"), "end_at": "18:45:10.250"}
+	var sh Shift
+	err := defaultScanner.singleResult(mp, &sh)
+	Assert(t, err, NilVal())
+	Assert(t, sh.StartAt, Equal(time.Date(0, 1, 1, 9, 30, 0, 0, time.UTC)))
+	Assert(t, *sh.EndAt, Equal(time.Date(0, 1, 1, 18, 45, 10, 250000000, time.UTC)))
+
+	//配置了时间格式时TIME列仍可解析
+	err = (&Scanner{TimeFormats: []string{time.RFC3339}}).singleResult(map[string]interface{}{"start_at": []byte("23:59:59")}, &sh)
+	Assert(t, err, NilVal())
+	Assert(t, sh.StartAt.Format(DefaultClockFormat), Equal("23:59:59"))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"start_at": []byte("25:00:00")}, &sh)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+func TestDBScanNullableTime(t *testing.T) {
+	type Event struct {
+		DeletedAt *time.Time `pg:"deleted_at"`
+	}
+	s := &Scanner{TimeFormats: []string{"02/01/2006 15:04"}}
+	expect := time.Date(2020, 5, 6, 7, 8, 0, 0, time.UTC)
+	for _, source := range []interface{}{expect, "06/05/2020 07:08", []byte("06/05/2020 07:08")} {
+		var e Event
+		err := s.singleResult(map[string]interface{}{"deleted_at": source}, &e)
+		Assert(t, err, NilVal())
+		Assert(t, e.DeletedAt.Equal(expect), Equal(true))
+	}
+
+	e := Event{DeletedAt: &expect}
+	err := s.singleResult(map[string]interface{}{"deleted_at": nil}, &e)
+	Assert(t, err, NilVal())
+	Assert(t, e.DeletedAt == nil, Equal(true))
+
+	//解析失败时不分配指针
+	err = s.singleResult(map[string]interface{}{"deleted_at": []byte("2020-05-06 07:08:00")}, &e)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	Assert(t, e.DeletedAt == nil, Equal(true))
+}
+
+func TestDBScanConvertErrorContext(t *testing.T) {
+	type Event struct {
+		CreatedAt time.Time `pg:"created_at"`
+	}
+	var e Event
+	err := defaultScanner.singleResult(map[string]interface{}{"created_at": []byte("bad")}, &e)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	Assert(t, err.Error(), Equal(`db_scan: column "created_at" -> field CreatedAt: 值类型转换失败`))
+}
+
+func TestDBScanStringNumber(t *testing.T) {
+	type Code string
+	type Product struct {
+		ID    int64   `pg:"id"`
+		Stock uint16  `pg:"stock"`
+		Price float64 `pg:"price"`
+	}
+	var p Product
+	err := defaultScanner.singleResult(map[string]interface{}{"id": "42", "stock": "7", "price": "9.99"}, &p)
+	Assert(t, err, NilVal())
+	Assert(t, p, Equal(Product{ID: 42, Stock: 7, Price: 9.99}))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"id": "abc"}, &p)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	type ProductText struct {
+		ID    string `pg:"id"`
+		Price string `pg:"price"`
+		Rate  Code   `pg:"rate"`
+	}
+	var pt ProductText
+	err = defaultScanner.singleResult(map[string]interface{}{"id": int64(42), "price": float64(9.99), "rate": float32(0.5)}, &pt)
+	Assert(t, err, NilVal())
+	Assert(t, pt, Equal(ProductText{ID: "42", Price: "9.99", Rate: "0.5"}))
+}
+
+func BenchmarkDBScanMultiResults(b *testing.B) {
+	type Person struct {
+		ID        int64     `pg:"id"`
+		Name      string    `pg:"name"`
+		Age       int       `pg:"age"`
+		Score     float64   `pg:"score"`
+		CreatedAt time.Time `pg:"created_at"`
+	}
+	now := time.Now()
+	data := make([]map[string]interface{}, 10000)
+	for i := range data {
+		data[i] = map[string]interface{}{
+			"id":         int64(i),
+			"name":       []byte("tencent"),
+			"age":        int64(20),
+			"score":      float64(99.5),
+			"created_at": now,
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var persons []Person
+		if err := defaultScanner.multiResults(data, &persons); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDBScanJSON(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+		Zip  int    `json:"zip"`
+	}
+	type User struct {
+		Attrs   map[string]interface{} `pg:"attrs"`
+		Address Address                `pg:"address"`
+		Tags    []string               `pg:"tags"`
+	}
+	var u User
+	var mp = map[string]interface{}{
+		"attrs":   []byte(`{"level":3,"vip":true}`),
+		"address": []byte(`{"city":"shenzhen","zip":518000}`),
+		"tags":    `["a","b"]`,
+	}
+	err := defaultScanner.singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.Attrs, Equal(map[string]interface{}{"level": float64(3), "vip": true}))
+	Assert(t, u.Address, Equal(Address{City: "shenzhen", Zip: 518000}))
+	Assert(t, u.Tags, Equal([]string{"a", "b"}))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"attrs": []byte(`{bad`)}, &u)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+func TestDBScanOverflow(t *testing.T) {
+	type Limits struct {
+		Small int8   `pg:"small"`
+		Port  uint16 `pg:"port"`
+	}
+	var l Limits
+	for _, source := range []interface{}{int64(300), []byte("300"), "300"} {
+		err := defaultScanner.singleResult(map[string]interface{}{"small": source}, &l)
+		Assert(t, errors.Is(err, ErrValueOverflow), Equal(true))
+		Assert(t, l.Small, Equal(int8(0)))
+	}
+	for _, source := range []interface{}{int64(70000), int64(-1), []byte("70000")} {
+		err := defaultScanner.singleResult(map[string]interface{}{"port": source}, &l)
+		Assert(t, errors.Is(err, ErrValueOverflow), Equal(true))
+	}
+	err := defaultScanner.singleResult(map[string]interface{}{"small": int64(-128), "port": []byte("65535")}, &l)
+	Assert(t, err, NilVal())
+	Assert(t, l, Equal(Limits{Small: -128, Port: 65535}))
+}
+
+func TestDBScanIntegerKinds(t *testing.T) {
+	type Counter struct {
+		ID     int64  `pg:"id"`
+		Hits   uint32 `pg:"hits"`
+		Total  uint64 `pg:"total"`
+		Active bool   `pg:"active"`
+		Label  string `pg:"label"`
+	}
+	var c Counter
+	err := defaultScanner.singleResult(map[string]interface{}{
+		"id":     int32(7),
+		"hits":   int(42),
+		"total":  uint64(math.MaxUint64),
+		"active": int8(1),
+		"label":  uint64(9),
+	}, &c)
+	Assert(t, err, NilVal())
+	Assert(t, c, Equal(Counter{ID: 7, Hits: 42, Total: math.MaxUint64, Active: true, Label: "9"}))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"id": uint64(math.MaxUint64)}, &c)
+	Assert(t, errors.Is(err, ErrValueOverflow), Equal(true))
+	err = defaultScanner.singleResult(map[string]interface{}{"hits": int32(-1)}, &c)
+	Assert(t, errors.Is(err, ErrValueOverflow), Equal(true))
+}
+
+func TestDBScanUint64Boundary(t *testing.T) {
+	type Row struct {
+		ID     uint64 `pg:"id"`
+		Signed int64  `pg:"signed"`
+	}
+	var r Row
+	err := defaultScanner.singleResult(map[string]interface{}{"id": uint64(math.MaxUint64), "signed": uint64(math.MaxInt64)}, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r, Equal(Row{ID: math.MaxUint64, Signed: math.MaxInt64}))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"id": []byte("18446744073709551615")}, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r.ID, Equal(uint64(math.MaxUint64)))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"signed": uint64(math.MaxInt64) + 1}, &r)
+	Assert(t, errors.Is(err, ErrValueOverflow), Equal(true))
+	err = defaultScanner.singleResult(map[string]interface{}{"id": []byte("18446744073709551616")}, &r)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+func TestDBScanFloatToInteger(t *testing.T) {
+	type Stock struct {
+		Count int    `pg:"count"`
+		Left  uint8  `pg:"left"`
+		Price string `pg:"price"`
+	}
+	var st Stock
+	err := defaultScanner.singleResult(map[string]interface{}{
+		"count": float64(3.0),
+		"left":  float32(7.9),
+		"price": float32(1.5),
+	}, &st)
+	Assert(t, err, NilVal())
+	Assert(t, st, Equal(Stock{Count: 3, Left: 7, Price: "1.5"}))
+
+	for _, source := range []interface{}{float64(-1), float64(256), math.NaN()} {
+		err = defaultScanner.singleResult(map[string]interface{}{"left": source}, &st)
+		Assert(t, errors.Is(err, ErrValueOverflow), Equal(true))
+	}
+	err = defaultScanner.singleResult(map[string]interface{}{"count": math.Inf(1)}, &st)
+	Assert(t, errors.Is(err, ErrValueOverflow), Equal(true))
+}
+
+func TestDBScanDefaultTag(t *testing.T) {
+	type Account struct {
+		Status  string  `pg:"status,default=active"`
+		Retries int     `pg:"retries,default=3"`
+		Ratio   float64 `pg:"ratio,default=0.5"`
+		Enabled bool    `pg:"enabled,default=true"`
+		Limit   *int64  `pg:"limit,default=100"`
+		Note    string  `pg:"note"`
+	}
+	var a Account
+	err := defaultScanner.singleResult(map[string]interface{}{"retries": nil, "note": nil}, &a)
+	Assert(t, err, NilVal())
+	Assert(t, a.Status, Equal("active"))
+	Assert(t, a.Retries, Equal(3))
+	Assert(t, a.Ratio, Equal(0.5))
+	Assert(t, a.Enabled, Equal(true))
+	Assert(t, *a.Limit, Equal(int64(100)))
+	Assert(t, a.Note, Equal(""))
+
+	a = Account{}
+	err = defaultScanner.singleResult(map[string]interface{}{"status": []byte("locked"), "retries": int64(0), "enabled": false}, &a)
+	Assert(t, err, NilVal())
+	Assert(t, a.Status, Equal("locked"))
+	Assert(t, a.Retries, Equal(0))
+	Assert(t, a.Enabled, Equal(false))
+
+	type Broken struct {
+		Count int `pg:"count,default=many"`
+	}
+	err = defaultScanner.singleResult(map[string]interface{}{}, &Broken{})
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+func TestDBScanInterfaceField(t *testing.T) {
+	type Row struct {
+		Name  interface{} `pg:"name"`
+		Count interface{} `pg:"count"`
+		Extra interface{} `pg:"extra"`
+	}
+	mp := map[string]interface{}{"name": []byte("tevid"), "count": int64(3), "extra": nil}
+	var r Row
+	err := defaultScanner.singleResult(mp, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r.Name, Equal(interface{}([]byte("tevid"))))
+	Assert(t, r.Count, Equal(interface{}(int64(3))))
+	Assert(t, r.Extra, NilVal())
+
+	r = Row{}
+	err = (&Scanner{BytesToString: true}).singleResult(mp, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r.Name, Equal(interface{}("tevid")))
+	Assert(t, r.Count, Equal(interface{}(int64(3))))
+}
+
+type Status string
+
+type Code int
+
+func TestDBScanNamedTypes(t *testing.T) {
+	type Order struct {
+		Status  Status  `pg:"status"`
+		Code    Code    `pg:"code"`
+		Backup  *Status `pg:"backup"`
+		Reason  Code    `pg:"reason"`
+		Comment Status  `pg:"comment"`
+	}
+	var o Order
+	err := defaultScanner.singleResult(map[string]interface{}{
+		"status":  []byte("paid"),
+		"code":    int64(200),
+		"backup":  "pending",
+		"reason":  []byte("404"),
+		"comment": int64(1),
+	}, &o)
+	Assert(t, err, NilVal())
+	Assert(t, o.Status, Equal(Status("paid")))
+	Assert(t, o.Code, Equal(Code(200)))
+	Assert(t, *o.Backup, Equal(Status("pending")))
+	Assert(t, o.Reason, Equal(Code(404)))
+	Assert(t, o.Comment, Equal(Status("1")))
+
+	var statuses []Status
+	err = defaultScanner.multiResults([]map[string]interface{}{{"status": []byte("a")}, {"status": "b"}}, &statuses)
+	Assert(t, err, NilVal())
+	Assert(t, statuses, Equal([]Status{"a", "b"}))
+}
+
+func TestDBScanNullLiterals(t *testing.T) {
+	type Metric struct {
+		Value  int64    `pg:"value"`
+		Ratio  *float64 `pg:"ratio"`
+		Name   string   `pg:"name"`
+		Weight int      `pg:"weight,default=1"`
+	}
+	mp := map[string]interface{}{"value": []byte("NULL"), "ratio": "null", "name": []byte("NULL"), "weight": []byte("null")}
+	var m Metric
+	err := defaultScanner.singleResult(mp, &m)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	m = Metric{}
+	err = (&Scanner{NullLiterals: []string{"NULL", "null"}}).singleResult(mp, &m)
+	Assert(t, err, NilVal())
+	Assert(t, m, Equal(Metric{Weight: 1}))
+
+	m = Metric{}
+	err = (&Scanner{NullLiterals: []string{"NULL"}}).singleResult(map[string]interface{}{"name": []byte("Null")}, &m)
+	Assert(t, err, NilVal())
+	Assert(t, m.Name, Equal("Null"))
+}
+
+func TestDBScanDuration(t *testing.T) {
+	type Job struct {
+		Timeout  time.Duration  `pg:"timeout"`
+		Interval time.Duration  `pg:"interval"`
+		Elapsed  time.Duration  `pg:"elapsed"`
+		Retry    *time.Duration `pg:"retry"`
+	}
+	var j Job
+	err := defaultScanner.singleResult(map[string]interface{}{
+		"timeout":  []byte("1h30m"),
+		"interval": int64(5 * time.Second),
+		"elapsed":  []byte("1500"),
+		"retry":    "250ms",
+	}, &j)
+	Assert(t, err, NilVal())
+	Assert(t, j.Timeout, Equal(90*time.Minute))
+	Assert(t, j.Interval, Equal(5*time.Second))
+	Assert(t, j.Elapsed, Equal(1500*time.Nanosecond))
+	Assert(t, *j.Retry, Equal(250*time.Millisecond))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"timeout": []byte("soon")}, &j)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+type defaultedUser struct {
+	Name     string `pg:"name"`
+	Nickname string `pg:"nickname"`
+	Age      int    `pg:"age"`
+	calls    int
+}
+
+func (u *defaultedUser) SetDefault() {
+	u.calls++
+	if u.Nickname == "" {
+		u.Nickname = u.Name
+	}
+}
+
+func TestDBScanDefaultSetter(t *testing.T) {
+	datas := []map[string]interface{}{
+		{"name": []byte("a"), "nickname": nil},
+		{"name": []byte("b"), "nickname": []byte("bee")},
+	}
+	var users []defaultedUser
+	err := defaultScanner.multiResults(datas, &users)
+	Assert(t, err, NilVal())
+	Assert(t, users, Equal([]defaultedUser{{Name: "a", Nickname: "a", calls: 1}, {Name: "b", Nickname: "bee", calls: 1}}))
+
+	var u *defaultedUser
+	err = defaultScanner.singleResult(datas[0], &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.calls, Equal(1))
+	Assert(t, u.Nickname, Equal("a"))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"age": []byte("old")}, &u)
+	Assert(t, err == nil, Equal(false))
+	Assert(t, u.calls, Equal(1))
+}
+
+func TestDBScanRawMessage(t *testing.T) {
+	type Event struct {
+		Payload json.RawMessage  `pg:"payload"`
+		Meta    json.RawMessage  `pg:"meta"`
+		Extra   *json.RawMessage `pg:"extra"`
+		Empty   json.RawMessage  `pg:"empty"`
+	}
+	var e Event
+	err := defaultScanner.singleResult(map[string]interface{}{
+		"payload": []byte(`{"id": 1, "tags": ["a"]}`),
+		"meta":    `[1,2]`,
+		"extra":   []byte(`"x"`),
+		"empty":   nil,
+	}, &e)
+	Assert(t, err, NilVal())
+	Assert(t, string(e.Payload), Equal(`{"id": 1, "tags": ["a"]}`))
+	Assert(t, string(e.Meta), Equal(`[1,2]`))
+	Assert(t, string(*e.Extra), Equal(`"x"`))
+	Assert(t, e.Empty == nil, Equal(true))
+
+	var payload struct {
+		ID   int      `json:"id"`
+		Tags []string `json:"tags"`
+	}
+	Assert(t, json.Unmarshal(e.Payload, &payload), NilVal())
+	Assert(t, payload.ID, Equal(1))
+	Assert(t, payload.Tags, Equal([]string{"a"}))
+}
+
+type Money int64
+
+type Ratio float64
+
+type smallInt int16
+
+func TestDBScanNamedNumeric(t *testing.T) {
+	type Account struct {
+		Balance Money      `pg:"balance"`
+		Credit  Money      `pg:"credit"`
+		Debt    Money      `pg:"debt"`
+		Rate    Ratio      `pg:"rate"`
+		Share   Ratio      `pg:"share"`
+		Level   Money      `pg:"level"`
+		Signal  complex128 `pg:"signal"`
+	}
+	var a Account
+	err := defaultScanner.singleResult(map[string]interface{}{
+		"balance": int32(100),
+		"credit":  float64(20.0),
+		"debt":    []byte("-5"),
+		"rate":    int64(3),
+		"share":   uint8(1),
+		"level":   smallInt(7),
+		"signal":  complex64(1 + 2i),
+	}, &a)
+	Assert(t, err, NilVal())
+	Assert(t, a, Equal(Account{Balance: 100, Credit: 20, Debt: -5, Rate: 3, Share: 1, Level: 7, Signal: 1 + 2i}))
+}
+
+func TestDBScanTreatEmptyAsZero(t *testing.T) {
+	type Legacy struct {
+		Count  int     `pg:"count"`
+		Score  float64 `pg:"score"`
+		Active bool    `pg:"active"`
+		Limit  *uint   `pg:"limit"`
+		Name   string  `pg:"name"`
+	}
+	mp := map[string]interface{}{"count": []byte(""), "score": []byte{}, "active": "", "limit": []byte(""), "name": []byte("")}
+	l := Legacy{Count: 1, Score: 1}
+	err := defaultScanner.singleResult(mp, &l)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	err = (&Scanner{TreatEmptyAsZero: true}).singleResult(mp, &l)
+	Assert(t, err, NilVal())
+	Assert(t, l.Count, Equal(0))
+	Assert(t, l.Score, Equal(0.0))
+	Assert(t, l.Active, Equal(false))
+	Assert(t, *l.Limit, Equal(uint(0)))
+	Assert(t, l.Name, Equal(""))
+}
+
+//模拟decimal类型，以字符串保存原始数值
+type fakeDecimal struct {
+	text string
+}
+
+func (d *fakeDecimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		d.text = string(v)
+	case string:
+		d.text = v
+	default:
+		return ErrConvertValue
+	}
+	return nil
+}
+
+func TestDBScanDecimalScanner(t *testing.T) {
+	type Account struct {
+		Balance fakeDecimal  `pg:"balance"`
+		Credit  *fakeDecimal `pg:"credit"`
+	}
+	var a Account
+	var mp = map[string]interface{}{
+		"balance": []byte("12345678901234567890.123456789"),
+		"credit":  "0.1000000000000000055511151231257827",
+	}
+	err := defaultScanner.singleResult(mp, &a)
+	Assert(t, err, NilVal())
+	Assert(t, a.Balance.text, Equal("12345678901234567890.123456789"))
+	Assert(t, a.Credit.text, Equal("0.1000000000000000055511151231257827"))
+}
+
+func TestDBScanBinary(t *testing.T) {
+	type Blob []byte
+	type RawBlob []byte
+	type File struct {
+		Data  []byte `pg:"data"`
+		Thumb Blob   `pg:"thumb"`
+	}
+	blob := []byte{0x00, 0xff, 0x10, '1', '2', 0x80, 0x00}
+	var f File
+	err := defaultScanner.singleResult(map[string]interface{}{"data": blob, "thumb": RawBlob(blob)}, &f)
+	Assert(t, err, NilVal())
+	Assert(t, bytes.Equal(f.Data, blob), Equal(true))
+	Assert(t, bytes.Equal(f.Thumb, blob), Equal(true))
+}
+
+func TestDBScanByteArray(t *testing.T) {
+	type UUID [16]byte
+	type Entity struct {
+		ID   UUID    `pg:"id"`
+		Hash [4]byte `pg:"hash"`
+	}
+	raw := []byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	var e Entity
+	err := defaultScanner.singleResult(map[string]interface{}{"id": raw, "hash": []byte{1, 2, 3, 4}}, &e)
+	Assert(t, err, NilVal())
+	Assert(t, e.ID[:], Equal(raw))
+	Assert(t, e.Hash, Equal([4]byte{1, 2, 3, 4}))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"id": raw[:15]}, &e)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	err = defaultScanner.singleResult(map[string]interface{}{"hash": []byte{1, 2, 3, 4, 5}}, &e)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+func TestDBScanTimeToUnix(t *testing.T) {
+	type Event struct {
+		CreatedAt     int64   `pg:"created_at"`
+		CreatedAtNano int64   `pg:"created_at,unixnano"`
+		CreatedAtSec  float64 `pg:"created_at"`
+		CreatedAtPtr  *int64  `pg:"created_at"`
+	}
+	created := time.Date(2020, 5, 6, 7, 8, 9, 500000000, time.UTC)
+	var e Event
+	err := defaultScanner.singleResult(map[string]interface{}{"created_at": created}, &e)
+	Assert(t, err, NilVal())
+	Assert(t, e.CreatedAt, Equal(created.Unix()))
+	Assert(t, e.CreatedAtNano, Equal(created.UnixNano()))
+	Assert(t, e.CreatedAtSec, Equal(float64(created.Unix())+0.5))
+	Assert(t, *e.CreatedAtPtr, Equal(created.Unix()))
+
+	type SmallEvent struct {
+		CreatedAt int16 `pg:"created_at"`
+	}
+	var se SmallEvent
+	err = defaultScanner.singleResult(map[string]interface{}{"created_at": created}, &se)
+	Assert(t, errors.Is(err, ErrValueOverflow), Equal(true))
+}
+
+func TestDBScanNetAddr(t *testing.T) {
+	type Device struct {
+		IPv4 net.IP           `pg:"ipv4"`
+		IPv6 net.IP           `pg:"ipv6"`
+		MAC  net.HardwareAddr `pg:"mac"`
+		Last *net.IP          `pg:"last_ip"`
+	}
+	mp := map[string]interface{}{
+		"ipv4":    []byte("192.168.1.10"),
+		"ipv6":    "2001:db8::1",
+		"mac":     []byte("08:00:2b:01:02:03"),
+		"last_ip": nil,
+	}
+	var d Device
+	err := defaultScanner.singleResult(mp, &d)
+	Assert(t, err, NilVal())
+	Assert(t, d.IPv4.Equal(net.IPv4(192, 168, 1, 10)), Equal(true))
+	Assert(t, d.IPv6.String(), Equal("2001:db8::1"))
+	Assert(t, d.MAC.String(), Equal("08:00:2b:01:02:03"))
+	Assert(t, d.Last == nil, Equal(true))
+
+	mp["last_ip"] = []byte("10.0.0.1")
+	err = defaultScanner.singleResult(mp, &d)
+	Assert(t, err, NilVal())
+	Assert(t, d.Last.String(), Equal("10.0.0.1"))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"ipv4": []byte("not-an-ip")}, &d)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	err = defaultScanner.singleResult(map[string]interface{}{"mac": "zz:zz"}, &d)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+func TestDBScanBigNumber(t *testing.T) {
+	type Account struct {
+		Balance    big.Int    `pg:"balance"`
+		BalancePtr *big.Int   `pg:"balance"`
+		Rate       big.Float  `pg:"rate"`
+		RatePtr    *big.Float `pg:"rate"`
+		Count      *big.Int   `pg:"count"`
+		Missing    *big.Int   `pg:"missing"`
+	}
+	balance := "123456789012345678901234567890123456789"
+	rate := "3.14159265358979323846264338327950288419716939937510"
+	mp := map[string]interface{}{
+		"balance": []byte(balance),
+		"rate":    rate,
+		"count":   int64(42),
+		"missing": nil,
+	}
+	var a Account
+	err := defaultScanner.singleResult(mp, &a)
+	Assert(t, err, NilVal())
+	Assert(t, a.Balance.String(), Equal(balance))
+	Assert(t, a.BalancePtr.String(), Equal(balance))
+	Assert(t, a.Rate.Text('f', 50), Equal(rate))
+	Assert(t, a.RatePtr.Text('f', 50), Equal(rate))
+	Assert(t, a.Count.Int64(), Equal(int64(42)))
+	Assert(t, a.Missing == nil, Equal(true))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"balance": []byte("12.5")}, &a)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	err = defaultScanner.singleResult(map[string]interface{}{"rate": []byte("abc")}, &a)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+func TestDBScanJSONNumber(t *testing.T) {
+	type Metric struct {
+		Count   int64        `pg:"count"`
+		Small   int8         `pg:"small"`
+		Total   uint         `pg:"total"`
+		Ratio   float64      `pg:"ratio"`
+		Score   *float32     `pg:"score"`
+		Raw     string       `pg:"ratio"`
+		Number  json.Number  `pg:"count"`
+		Dynamic interface{}  `pg:"count"`
+		Ptr     *json.Number `pg:"ratio"`
+	}
+	mp := map[string]interface{}{
+		"count": json.Number("42"),
+		"small": json.Number("-8"),
+		"total": json.Number("7"),
+		"ratio": json.Number("0.25"),
+		"score": json.Number("1.5"),
+	}
+	var m Metric
+	err := defaultScanner.singleResult(mp, &m)
+	Assert(t, err, NilVal())
+	Assert(t, m.Count, Equal(int64(42)))
+	Assert(t, m.Small, Equal(int8(-8)))
+	Assert(t, m.Total, Equal(uint(7)))
+	Assert(t, m.Ratio, Equal(0.25))
+	Assert(t, *m.Score, Equal(float32(1.5)))
+	Assert(t, m.Raw, Equal("0.25"))
+	Assert(t, m.Number, Equal(json.Number("42")))
+	Assert(t, m.Dynamic, Equal(json.Number("42")))
+	Assert(t, *m.Ptr, Equal(json.Number("0.25")))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"count": json.Number("0.5")}, &m)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	err = defaultScanner.singleResult(map[string]interface{}{"small": json.Number("300")}, &m)
+	Assert(t, errors.Is(err, ErrValueOverflow), Equal(true))
+}
+
+func TestDBScanIntBase(t *testing.T) {
+	type Record struct {
+		Flags  int64  `pg:"flags,base=16"`
+		Mask   uint32 `pg:"mask,base=0"`
+		Mode   int    `pg:"mode,base=0"`
+		Bits   *uint8 `pg:"bits,base=2"`
+		Serial int64  `pg:"serial"`
+	}
+	mp := map[string]interface{}{
+		"flags":  []byte("ff"),
+		"mask":   "0xFF00",
+		"mode":   []byte("0o755"),
+		"bits":   []byte("1010"),
+		"serial": []byte("010"),
+	}
+	var r Record
+	err := defaultScanner.singleResult(mp, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r.Flags, Equal(int64(255)))
+	Assert(t, r.Mask, Equal(uint32(0xFF00)))
+	Assert(t, r.Mode, Equal(0755))
+	Assert(t, *r.Bits, Equal(uint8(10)))
+	Assert(t, r.Serial, Equal(int64(10)))
+
+	//与进制一致的前缀可省略
+	err = defaultScanner.singleResult(map[string]interface{}{"flags": []byte("0xFF"), "bits": []byte("0B11")}, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r.Flags, Equal(int64(255)))
+	Assert(t, *r.Bits, Equal(uint8(3)))
+	err = defaultScanner.singleResult(map[string]interface{}{"flags": "-0x10"}, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r.Flags, Equal(int64(-16)))
+	err = defaultScanner.singleResult(map[string]interface{}{"flags": []byte("0x")}, &r)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	err = defaultScanner.singleResult(map[string]interface{}{"bits": []byte("0x11")}, &r)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	type Invalid struct {
+		ID int64 `pg:"id,base=x"`
+	}
+	var inv Invalid
+	err = defaultScanner.singleResult(map[string]interface{}{"id": []byte("1")}, &inv)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+func TestDBScanPtrSlice(t *testing.T) {
+	type User struct {
+		ID   int64  `pg:"id"`
+		Name string `pg:"name"`
+	}
+	rows := newMockRows([]string{"id", "name"},
+		[]interface{}{int64(1), []byte("a")},
+		[]interface{}{int64(2), []byte("b")},
+	)
+	var users []*User
+	err := Scan(rows, &users)
+	Assert(t, err, NilVal())
+	Assert(t, len(users), Equal(2))
+	Assert(t, users[0] != nil && users[1] != nil, Equal(true))
+	Assert(t, users[0] != users[1], Equal(true))
+	Assert(t, *users[0], Equal(User{ID: 1, Name: "a"}))
+	Assert(t, *users[1], Equal(User{ID: 2, Name: "b"}))
+
+	var ids []*int64
+	err = Scan(newMockRows([]string{"id"}, []interface{}{int64(1)}, []interface{}{nil}), &ids)
+	Assert(t, err, NilVal())
+	Assert(t, len(ids), Equal(2))
+	Assert(t, *ids[0], Equal(int64(1)))
+	Assert(t, ids[1] == nil, Equal(true))
+}
+
+func TestDBScanSqlNullTypes(t *testing.T) {
+	type Record struct {
+		Name    sql.NullString  `pg:"name"`
+		Age     sql.NullInt64   `pg:"age"`
+		Score   sql.NullFloat64 `pg:"score"`
+		Enabled sql.NullBool    `pg:"enabled"`
+		Created sql.NullTime    `pg:"created"`
+		Updated *sql.NullTime   `pg:"updated"`
+	}
+	created := time.Date(2020, 5, 6, 7, 8, 9, 0, time.UTC)
+	mp := map[string]interface{}{
+		"name":    []byte("tencent"),
+		"age":     int64(20),
+		"score":   99.5,
+		"enabled": true,
+		"created": created,
+		"updated": created,
+	}
+	var r Record
+	err := defaultScanner.singleResult(mp, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r.Name, Equal(sql.NullString{String: "tencent", Valid: true}))
+	Assert(t, r.Age, Equal(sql.NullInt64{Int64: 20, Valid: true}))
+	Assert(t, r.Score, Equal(sql.NullFloat64{Float64: 99.5, Valid: true}))
+	Assert(t, r.Enabled, Equal(sql.NullBool{Bool: true, Valid: true}))
+	Assert(t, r.Created, Equal(sql.NullTime{Time: created, Valid: true}))
+	Assert(t, *r.Updated, Equal(sql.NullTime{Time: created, Valid: true}))
+
+	//驱动以文本返回的值同样由Scan转换
+	err = defaultScanner.singleResult(map[string]interface{}{"age": []byte("21"), "score": []byte("1.5"), "enabled": []byte("f")}, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r.Age, Equal(sql.NullInt64{Int64: 21, Valid: true}))
+	Assert(t, r.Score, Equal(sql.NullFloat64{Float64: 1.5, Valid: true}))
+	Assert(t, r.Enabled, Equal(sql.NullBool{Bool: false, Valid: true}))
+
+	//NULL重置之前的有效值
+	for column := range mp {
+		mp[column] = nil
+	}
+	err = defaultScanner.singleResult(mp, &r)
+	Assert(t, err, NilVal())
+	Assert(t, r, Equal(Record{}))
+}
+
+func TestDBScanNumericUnsupportedTarget(t *testing.T) {
+	var r struct {
+		At     time.Time `pg:"at"`
+		IDs    []int     `pg:"ids"`
+		Nested struct {
+			ID int `pg:"id"`
+		} `pg:"nested"`
+	}
+	for _, column := range []string{"at", "ids", "nested"} {
+		for _, source := range []interface{}{int64(1), int32(1), uint64(1), float64(1), float32(1)} {
+			err := defaultScanner.singleResult(map[string]interface{}{column: source}, &r)
+			Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+		}
+	}
+
+	var flag struct {
+		Enabled bool `pg:"enabled"`
+	}
+	err := defaultScanner.singleResult(map[string]interface{}{"enabled": 1.0}, &flag)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+func TestDBScanIntToBool(t *testing.T) {
+	type Setting struct {
+		Enabled bool  `pg:"enabled"`
+		Visible *bool `pg:"visible"`
+	}
+	testCases := []struct {
+		source interface{}
+		expect bool
+	}{
+		{int64(1), true},
+		{int64(0), false},
+		{int64(2), true},
+		{int64(-1), true},
+		{int8(1), true},
+		{uint8(0), false},
+		{uint64(1), true},
+	}
+	for _, tc := range testCases {
+		s := Setting{Enabled: !tc.expect}
+		err := defaultScanner.singleResult(map[string]interface{}{"enabled": tc.source, "visible": tc.source}, &s)
+		Assert(t, err, NilVal())
+		Assert(t, s.Enabled, Equal(tc.expect))
+		Assert(t, *s.Visible, Equal(tc.expect))
+	}
+}
+
+func TestDBScanUnsupportedBytesTarget(t *testing.T) {
+	type Record struct {
+		Value complex128 `pg:"value"`
+		Ch    chan int   `pg:"ch"`
+	}
+	var r Record
+	err := defaultScanner.singleResult(map[string]interface{}{"value": []byte("1+2i")}, &r)
+	Assert(t, errors.Is(err, ErrUnSupportTypeConvert), Equal(true))
+	Assert(t, strings.Contains(err.Error(), "[]byte(len=4) -> complex128(kind=complex128)"), Equal(true))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"ch": []byte("x")}, &r)
+	Assert(t, errors.Is(err, ErrUnSupportTypeConvert), Equal(true))
+	Assert(t, strings.Contains(err.Error(), "[]byte(len=1) -> chan int(kind=chan)"), Equal(true))
+}
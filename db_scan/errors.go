@@ -0,0 +1,94 @@
+package db_scan
+
+import (
+	"fmt"
+	"strings"
+)
+
+//ContinueOnError模式下单行内收集到的字段转换错误
+type fieldErrors []error
+
+func (e fieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+//单行数据的转换错误
+type RowError struct {
+	Row    int     //出错的行号，从0开始
+	Errors []error //该行各字段的转换错误
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("db_scan: row %d: %s", e.Row, fieldErrors(e.Errors).Error())
+}
+
+func (e *RowError) Unwrap() []error {
+	return e.Errors
+}
+
+//ContinueOnError模式下汇总的转换错误，目标对象中仍保留成功转换的行与字段
+type MultiError struct {
+	Rows []*RowError
+}
+
+func (e *MultiError) Error() string {
+	msgs := make([]string, len(e.Rows))
+	for i, rowErr := range e.Rows {
+		msgs[i] = rowErr.Error()
+	}
+	return fmt.Sprintf("db_scan: %d row(s) failed to convert: %s", len(e.Rows), strings.Join(msgs, "; "))
+}
+
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Rows))
+	for i, rowErr := range e.Rows {
+		errs[i] = rowErr
+	}
+	return errs
+}
+
+//SkipScanErrors模式下因rows.Scan出错而跳过的行，其余行已正常写入目标对象
+type SkippedRowsError struct {
+	Rows []*RowError //Row为该行在结果集中的行号
+}
+
+func (e *SkippedRowsError) Error() string {
+	msgs := make([]string, len(e.Rows))
+	for i, rowErr := range e.Rows {
+		msgs[i] = rowErr.Error()
+	}
+	return fmt.Sprintf("db_scan: %d row(s) skipped on scan error: %s", len(e.Rows), strings.Join(msgs, "; "))
+}
+
+func (e *SkippedRowsError) Unwrap() []error {
+	errs := make([]error, len(e.Rows))
+	for i, rowErr := range e.Rows {
+		errs[i] = rowErr
+	}
+	return errs
+}
+
+//值转换失败的具体原因，errors.Is可匹配ErrConvertValue，errors.As可取出底层错误（如*strconv.NumError）
+type ConvertError struct {
+	Err error //底层的解析错误
+}
+
+func convertError(err error) error {
+	return &ConvertError{Err: err}
+}
+
+func (e *ConvertError) Error() string {
+	return ErrConvertValue.Error() + ": " + e.Err.Error()
+}
+
+func (e *ConvertError) Is(target error) bool {
+	return target == ErrConvertValue
+}
+
+func (e *ConvertError) Unwrap() error {
+	return e.Err
+}
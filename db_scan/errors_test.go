@@ -0,0 +1,85 @@
+package db_scan
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+func TestScanContinueOnError(t *testing.T) {
+	type Stu struct {
+		Age  int8   `pg:"age"`
+		Name string `pg:"name"`
+		Rank int    `pg:"rank"`
+	}
+	rows := newMockRows([]string{"age", "name", "rank"},
+		[]interface{}{int64(1), []byte("a"), []byte("1")},
+		[]interface{}{int64(300), []byte("b"), []byte("x")},
+		[]interface{}{int64(3), []byte("c"), []byte("3")},
+	)
+	var students []Stu
+	n, err := (&Scanner{ContinueOnError: true}).ScanN(rows, &students)
+	Assert(t, n, Equal(3))
+	Assert(t, students, Equal([]Stu{{1, "a", 1}, {0, "b", 0}, {3, "c", 3}}))
+
+	var multiErr *MultiError
+	Assert(t, errors.As(err, &multiErr), Equal(true))
+	Assert(t, len(multiErr.Rows), Equal(1))
+	Assert(t, multiErr.Rows[0].Row, Equal(1))
+	Assert(t, len(multiErr.Rows[0].Errors), Equal(2))
+	Assert(t, errors.Is(multiErr.Rows[0].Errors[0], ErrValueOverflow), Equal(true))
+	Assert(t, errors.Is(multiErr.Rows[0].Errors[1], ErrConvertValue), Equal(true))
+
+	students = nil
+	rows = newMockRows([]string{"age", "name", "rank"},
+		[]interface{}{int64(1), []byte("a"), []byte("1")},
+		[]interface{}{int64(300), []byte("b"), []byte("x")},
+	)
+	_, err = ScanN(rows, &students)
+	Assert(t, errors.Is(err, ErrValueOverflow), Equal(true))
+	Assert(t, students == nil, Equal(true))
+}
+
+func TestScanContinueOnErrorSingle(t *testing.T) {
+	type Stu struct {
+		Age  int8   `pg:"age"`
+		Name string `pg:"name"`
+	}
+	var stu Stu
+	err := (&Scanner{ContinueOnError: true}).Scan(newMockRows([]string{"age", "name"}, []interface{}{int64(300), []byte("a")}), &stu)
+	var multiErr *MultiError
+	Assert(t, errors.As(err, &multiErr), Equal(true))
+	Assert(t, multiErr.Rows[0].Row, Equal(0))
+	Assert(t, stu, Equal(Stu{Name: "a"}))
+}
+
+func TestScanContinueOnErrorPtrTarget(t *testing.T) {
+	type Stu struct {
+		Age  int8   `pg:"age"`
+		Name string `pg:"name"`
+	}
+	var stu *Stu
+	err := (&Scanner{ContinueOnError: true}).Scan(newMockRows([]string{"age", "name"}, []interface{}{int64(300), []byte("a")}), &stu)
+	Assert(t, err, Not(NilVal()))
+	Assert(t, *stu, Equal(Stu{Name: "a"}))
+}
+
+func TestConvertError(t *testing.T) {
+	type Stat struct {
+		Count int8    `pg:"count"`
+		Ratio float64 `pg:"ratio"`
+	}
+	var st Stat
+	err := defaultScanner.singleResult(map[string]interface{}{"ratio": []byte("abc")}, &st)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	var numErr *strconv.NumError
+	Assert(t, errors.As(err, &numErr), Equal(true))
+	Assert(t, numErr.Err, Equal(strconv.ErrSyntax))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"count": "99999999999999999999"}, &st)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+	Assert(t, errors.As(err, &numErr), Equal(true))
+	Assert(t, numErr.Err, Equal(strconv.ErrRange))
+}
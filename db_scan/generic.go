@@ -0,0 +1,46 @@
+package db_scan
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+//扫描数据集为[]T，T可以是结构体、结构体指针或基础类型，没有数据时返回nil
+func ScanSlice[T any](rows IRows) ([]T, error) {
+	var result []T
+	err := Scan(rows, &result)
+	return result, err
+}
+
+//扫描数据集的第一行为T，没有数据时返回ErrEmptyResult
+func ScanStruct[T any](rows IRows) (T, error) {
+	var result T
+	err := Scan(rows, &result)
+	return result, err
+}
+
+//提取数据集中名为column的列，每行的值转换为T，没有数据时返回nil
+//结果集中没有该列时返回ErrMissingColumn
+func ScanColumn[T any](rows IRows, column string) (result []T, err error) {
+	defer closeRows(rows, &err)
+	columns, err := rowColumns(rows)
+	if nil != err {
+		return nil, err
+	}
+	if !containsString(columns, column) {
+		return nil, fmt.Errorf("%w: %s", ErrMissingColumn, column)
+	}
+	datas, _, err := defaultScanner.extraDatas(context.Background(), rows, &result)
+	if nil != err {
+		return nil, err
+	}
+	for _, row := range datas {
+		var elem T
+		if err = defaultScanner.valueConvert(row[column], reflect.ValueOf(&elem).Elem(), ""); nil != err {
+			return nil, wrapConvertError(column, "", err)
+		}
+		result = append(result, elem)
+	}
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package db_scan
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+func TestScanGeneric(t *testing.T) {
+	type User struct {
+		ID   int64  `pg:"id"`
+		Name string `pg:"name"`
+	}
+	newRows := func() *mockRows {
+		return newMockRows([]string{"id", "name"}, []interface{}{int64(1), []byte("a")}, []interface{}{int64(2), []byte("b")})
+	}
+	users, err := ScanSlice[User](newRows())
+	Assert(t, err, NilVal())
+	Assert(t, users, Equal([]User{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}))
+
+	u, err := ScanStruct[*User](newRows())
+	Assert(t, err, NilVal())
+	Assert(t, *u, Equal(User{ID: 1, Name: "a"}))
+
+	count, err := ScanStruct[int64](newMockRows([]string{"count"}, []interface{}{int64(2)}))
+	Assert(t, err, NilVal())
+	Assert(t, count, Equal(int64(2)))
+
+	_, err = ScanStruct[User](newMockRows([]string{"id"}))
+	Assert(t, err, Equal(ErrEmptyResult))
+	empty, err := ScanSlice[User](newMockRows([]string{"id"}))
+	Assert(t, err, NilVal())
+	Assert(t, empty == nil, Equal(true))
+}
+
+func TestScanColumn(t *testing.T) {
+	newRows := func() *mockRows {
+		return newMockRows([]string{"id", "name"}, []interface{}{int64(1), []byte("a")}, []interface{}{int64(2), nil})
+	}
+	ids, err := ScanColumn[int64](newRows(), "id")
+	Assert(t, err, NilVal())
+	Assert(t, ids, Equal([]int64{1, 2}))
+
+	names, err := ScanColumn[string](newRows(), "name")
+	Assert(t, err, NilVal())
+	Assert(t, names, Equal([]string{"a", ""}))
+
+	ptrs, err := ScanColumn[*string](newRows(), "name")
+	Assert(t, err, NilVal())
+	Assert(t, *ptrs[0], Equal("a"))
+	Assert(t, ptrs[1] == nil, Equal(true))
+
+	rows := newRows()
+	_, err = ScanColumn[int64](rows, "age")
+	Assert(t, errors.Is(err, ErrMissingColumn), Equal(true))
+	Assert(t, rows.closed, Equal(true))
+
+	_, err = ScanColumn[int64](newRows(), "name")
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	empty, err := ScanColumn[int64](newMockRows([]string{"id"}), "id")
+	Assert(t, err, NilVal())
+	Assert(t, empty == nil, Equal(true))
+}
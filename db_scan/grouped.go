@@ -0,0 +1,207 @@
+package db_scan
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//一对多分组扫描中子结构体slice字段的信息
+type groupField struct {
+	index   int
+	prefix  string       //子结构体列名前缀
+	elem    reflect.Type //子结构体类型
+	ptr     bool         //slice元素是否为结构体指针
+	scanner *Scanner     //按去掉前缀后的列顺序匹配子结构体字段的扫描器
+}
+
+//按groupKey列分组扫描join查询的结果，groupKey值相同的行合并为一个父对象，target须为结构体或结构体指针的slice
+//父对象的字段按普通规则匹配列；子对象为设置了prefix选项的结构体slice字段，如Items []Item `pg:"item_,prefix"`，
+//按"前缀+子字段列名"匹配列；某行的子对象列全部为NULL时（LEFT JOIN没有子记录）不追加子对象
+func ScanGrouped(rows IRows, target interface{}, groupKey string) error {
+	return defaultScanner.ScanGrouped(rows, target, groupKey)
+}
+
+//使用扫描器的配置进行一对多分组扫描
+func (s *Scanner) ScanGrouped(rows IRows, target interface{}, groupKey string) (err error) {
+	defer closeRows(rows, &err)
+	if !isSettableTarget(target) {
+		return ErrTargetNotSettable
+	}
+	sliceType := getPtrObjectType(target)
+	if sliceType.Kind() != reflect.Slice {
+		return ErrNotStruct
+	}
+	parentType, parentPtr, ok := structType(sliceType.Elem())
+	if !ok {
+		return ErrNotStruct
+	}
+	datas, columns, err := s.extraDatas(context.Background(), rows, target)
+	skipped, partial := err.(*SkippedRowsError)
+	if nil != err && !partial {
+		return err
+	}
+	scanner := s.withColumns(columns)
+	children := s.groupFields(parentType, columns)
+	parents := make(map[interface{}]reflect.Value)
+	var ordered []reflect.Value
+	var rowErrors []*RowError
+	for i, row := range datas {
+		keyVal, exist := row[groupKey]
+		if !exist {
+			return fmt.Errorf("%w: %s", ErrMissingColumn, groupKey)
+		}
+		var errs fieldErrors
+		parent, exist := parents[groupValue(keyVal)]
+		if !exist {
+			parent = reflect.New(parentType)
+			if err = scanner.singleResult(row, parent.Interface()); nil != err {
+				if errs, ok = err.(fieldErrors); !ok {
+					return err
+				}
+			}
+			parents[groupValue(keyVal)] = parent
+			ordered = append(ordered, parent)
+		}
+		for _, child := range children {
+			childErrs, err := child.scanner.appendChild(row, parent.Elem().Field(child.index), child)
+			if nil != err {
+				return err
+			}
+			errs = append(errs, childErrs...)
+		}
+		if len(errs) > 0 {
+			rowErrors = append(rowErrors, &RowError{Row: i, Errors: errs})
+		}
+	}
+	result := reflect.MakeSlice(sliceType, 0, len(ordered))
+	for _, parent := range ordered {
+		result = reflect.Append(result, childValue(parent, parentPtr))
+	}
+	getPtrObjectValue(target).Set(result)
+	if len(rowErrors) > 0 {
+		return &MultiError{Rows: rowErrors}
+	}
+	if partial {
+		return skipped
+	}
+	return nil
+}
+
+//按keyColumn列的值对数据行分组，用于构建索引；[]byte类型的键转换为string
+//数据集中不包含keyColumn列时返回ErrMissingColumn
+func ScanMapBy(rows IRows, keyColumn string) (map[interface{}][]map[string]interface{}, error) {
+	return defaultScanner.ScanMapBy(rows, keyColumn)
+}
+
+//使用扫描器的配置按列值对数据行分组
+func (s *Scanner) ScanMapBy(rows IRows, keyColumn string) (result map[interface{}][]map[string]interface{}, err error) {
+	defer closeRows(rows, &err)
+	columns, err := rowColumns(rows)
+	if nil != err {
+		return nil, err
+	}
+	if !containsString(columns, keyColumn) {
+		return nil, fmt.Errorf("%w: %s", ErrMissingColumn, keyColumn)
+	}
+	//行数据直接返回给调用方，以*[]map[string]interface{}作为目标避免复用行map
+	var datas []map[string]interface{}
+	datas, _, err = s.extraDatas(context.Background(), rows, &datas)
+	skipped, partial := err.(*SkippedRowsError)
+	if nil != err && !partial {
+		return nil, err
+	}
+	result = make(map[interface{}][]map[string]interface{})
+	for _, row := range datas {
+		key := groupValue(row[keyColumn])
+		result[key] = append(result[key], row)
+	}
+	if partial {
+		return result, skipped
+	}
+	return result, nil
+}
+
+func containsString(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
+//获取结构体中设置了prefix选项的子结构体slice字段，columns为结果集的列名
+func (s *Scanner) groupFields(typeObj reflect.Type, columns []string) []groupField {
+	var fields []groupField
+	for i := 0; i < typeObj.NumField(); i++ {
+		field := typeObj.Field(i)
+		tagName, opts, _ := s.lookupTag(field)
+		if field.PkgPath != "" || !opts.has(TagOptionPrefix) {
+			continue
+		}
+		if elem, ptr, ok := groupElemType(field.Type); ok {
+			fields = append(fields, groupField{index: i, prefix: tagName, elem: elem, ptr: ptr, scanner: s.withColumns(trimPrefixes(columns, tagName))})
+		}
+	}
+	return fields
+}
+
+//取出带有prefix前缀的列并去掉前缀，保持列顺序
+func trimPrefixes(columns []string, prefix string) []string {
+	var trimmed []string
+	for _, column := range columns {
+		if strings.HasPrefix(column, prefix) {
+			trimmed = append(trimmed, column[len(prefix):])
+		}
+	}
+	return trimmed
+}
+
+//判断类型是否为结构体或结构体指针的slice
+func groupElemType(t reflect.Type) (reflect.Type, bool, bool) {
+	if t.Kind() != reflect.Slice {
+		return nil, false, false
+	}
+	return structType(t.Elem())
+}
+
+//从行数据中取出子对象的列追加到子结构体slice，子对象列全部为NULL时不追加
+func (s *Scanner) appendChild(row map[string]interface{}, sliceObj reflect.Value, child groupField) (fieldErrors, error) {
+	childRow := make(map[string]interface{})
+	allNull := true
+	for column, value := range row {
+		if strings.HasPrefix(column, child.prefix) {
+			childRow[column[len(child.prefix):]] = value
+			allNull = allNull && value == nil
+		}
+	}
+	if allNull {
+		return nil, nil
+	}
+	elem := reflect.New(child.elem)
+	err := s.singleResult(childRow, elem.Interface())
+	errs, partial := err.(fieldErrors)
+	if nil != err && !partial {
+		return nil, err
+	}
+	sliceObj.Set(reflect.Append(sliceObj, childValue(elem, child.ptr)))
+	return errs, nil
+}
+
+//按元素类型取结构体指针或其指向的值
+func childValue(elem reflect.Value, ptr bool) reflect.Value {
+	if ptr {
+		return elem
+	}
+	return elem.Elem()
+}
+
+//分组键的值，[]byte不可作为map的键，转换为string
+func groupValue(value interface{}) interface{} {
+	if bytesVal, ok := value.([]byte); ok {
+		return string(bytesVal)
+	}
+	return value
+}
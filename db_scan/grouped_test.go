@@ -0,0 +1,83 @@
+package db_scan
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+func TestScanGrouped(t *testing.T) {
+	type Item struct {
+		SKU string `pg:"sku"`
+		Qty int    `pg:"qty"`
+	}
+	type Order struct {
+		ID    int64   `pg:"id"`
+		Buyer string  `pg:"buyer"`
+		Items []Item  `pg:"item_,prefix"`
+		Gifts []*Item `pg:"gift_,prefix"`
+	}
+	columns := []string{"id", "buyer", "item_sku", "item_qty", "gift_sku", "gift_qty"}
+	newRows := func() *mockRows {
+		return newMockRows(columns,
+			[]interface{}{int64(1), []byte("alice"), []byte("apple"), int64(2), nil, nil},
+			[]interface{}{int64(2), []byte("bob"), nil, nil, nil, nil},
+			[]interface{}{int64(1), []byte("alice"), []byte("pear"), int64(1), []byte("card"), int64(1)},
+		)
+	}
+	var orders []Order
+	err := ScanGrouped(newRows(), &orders, "id")
+	Assert(t, err, NilVal())
+	Assert(t, len(orders), Equal(2))
+	Assert(t, orders[0].ID, Equal(int64(1)))
+	Assert(t, orders[0].Buyer, Equal("alice"))
+	Assert(t, orders[0].Items, Equal([]Item{{SKU: "apple", Qty: 2}, {SKU: "pear", Qty: 1}}))
+	Assert(t, orders[0].Gifts, Equal([]*Item{{SKU: "card", Qty: 1}}))
+	Assert(t, orders[1].ID, Equal(int64(2)))
+	Assert(t, orders[1].Items == nil, Equal(true))
+
+	var ptrs []*Order
+	err = ScanGrouped(newRows(), &ptrs, "buyer")
+	Assert(t, err, NilVal())
+	Assert(t, len(ptrs), Equal(2))
+	Assert(t, len(ptrs[0].Items), Equal(2))
+
+	err = ScanGrouped(newRows(), &orders, "order_id")
+	Assert(t, errors.Is(err, ErrMissingColumn), Equal(true))
+
+	var ids []int64
+	err = ScanGrouped(newRows(), &ids, "id")
+	Assert(t, err, Equal(ErrNotStruct))
+
+	//普通扫描忽略子结构体slice字段
+	var flat []Order
+	err = Scan(newRows(), &flat)
+	Assert(t, err, NilVal())
+	Assert(t, len(flat), Equal(3))
+	Assert(t, flat[0].Items == nil, Equal(true))
+}
+
+func TestScanMapBy(t *testing.T) {
+	rows := newMockRows([]string{"dept", "name"},
+		[]interface{}{[]byte("rd"), []byte("a")},
+		[]interface{}{[]byte("hr"), []byte("b")},
+		[]interface{}{[]byte("rd"), []byte("c")},
+		[]interface{}{nil, []byte("d")},
+	)
+	index, err := ScanMapBy(rows, "dept")
+	Assert(t, err, NilVal())
+	Assert(t, len(index), Equal(3))
+	Assert(t, len(index["rd"]), Equal(2))
+	Assert(t, index["rd"][1]["name"], Equal(interface{}([]byte("c"))))
+	Assert(t, len(index[nil]), Equal(1))
+	Assert(t, rows.closed, Equal(true))
+
+	rows = newMockRows([]string{"id"}, []interface{}{int64(1)}, []interface{}{int64(1)})
+	index, err = ScanMapBy(rows, "id")
+	Assert(t, err, NilVal())
+	Assert(t, len(index[int64(1)]), Equal(2))
+
+	_, err = ScanMapBy(newMockRows([]string{"id"}), "dept")
+	Assert(t, errors.Is(err, ErrMissingColumn), Equal(true))
+}
@@ -0,0 +1,464 @@
+package db_scan
+
+import (
+	"database/sql"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//结构体字段的映射信息
+type fieldInfo struct {
+	index  int         //字段下标
+	name   string      //字段名
+	column string      //对应的列名
+	alts   []string    //标签中以"|"分隔的候选列名，column不存在时依次查找，如pg:"created_at|creation_time"
+	auto   bool        //列名是否由字段名自动推导
+	mapped bool        //是否按Mapper转换后的列名匹配，此时column为字段名
+	opts   tagOptions  //标签选项
+	traits typeTraits  //字段类型实现的转换接口
+	nested []fieldInfo //嵌入结构体或按前缀映射的子结构体字段，非空时表示该字段为结构体
+	ptr    bool        //子结构体字段是否为结构体指针
+	extra  bool        //是否为接收未匹配列的map字段
+}
+
+//字段映射信息缓存的键，映射结果只与类型及影响映射的配置相关
+type fieldCacheKey struct {
+	typ      reflect.Type
+	tagNames string
+	autoMap  bool
+	mapper   bool
+	byName   bool
+}
+
+//结构体字段映射信息缓存：fieldCacheKey -> []fieldInfo
+var fieldCache sync.Map
+
+var (
+	sqlScannerType        = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	columnUnmarshalerType = reflect.TypeOf((*ColumnUnmarshaler)(nil)).Elem()
+	mapInterfaceType      = reflect.TypeOf(map[string]interface{}(nil))
+)
+
+//类型（或其指针）实现的转换接口，按类型预先判断，避免每次转换都通过Interface()做类型断言
+type typeTraits uint8
+
+const (
+	traitUnmarshaler typeTraits = 1 << iota //实现了ColumnUnmarshaler
+	traitSqlScanner                         //实现了sql.Scanner
+)
+
+func typeTraitsOf(t reflect.Type) typeTraits {
+	var traits typeTraits
+	if t.Implements(columnUnmarshalerType) || reflect.PtrTo(t).Implements(columnUnmarshalerType) {
+		traits |= traitUnmarshaler
+	}
+	if t.Implements(sqlScannerType) || reflect.PtrTo(t).Implements(sqlScannerType) {
+		traits |= traitSqlScanner
+	}
+	return traits
+}
+
+//获取结构体需要赋值的字段信息，同一类型及配置只解析一次
+func (s *Scanner) structFields(typeObj reflect.Type) []fieldInfo {
+	key := fieldCacheKey{typ: typeObj, tagNames: strings.Join(s.tagNames(), ","), autoMap: s.AutoMap, mapper: s.Mapper != nil, byName: s.MatchFieldName}
+	if cached, ok := fieldCache.Load(key); ok {
+		return cached.([]fieldInfo)
+	}
+	fields := s.buildFields(typeObj, "", map[reflect.Type]bool{})
+	cached, _ := fieldCache.LoadOrStore(key, fields)
+	return cached.([]fieldInfo)
+}
+
+//解析结构体字段，prefix为子结构体的列名前缀，visited用于避免结构体循环引用
+func (s *Scanner) buildFields(typeObj reflect.Type, prefix string, visited map[reflect.Type]bool) []fieldInfo {
+	visited[typeObj] = true
+	defer delete(visited, typeObj)
+
+	var fields []fieldInfo
+	for i := 0; i < typeObj.NumField(); i++ {
+		fieldTypeI := typeObj.Field(i)
+		if s.isExtraField(fieldTypeI) {
+			if fieldTypeI.PkgPath == "" {
+				fields = append(fields, fieldInfo{index: i, name: fieldTypeI.Name, extra: true})
+			}
+			continue
+		}
+		tagName, opts, tagged := s.lookupTag(fieldTypeI)
+		if tagName == SkipTagValue {
+			continue
+		}
+		nestedType, ptr, ok := s.embeddedStruct(fieldTypeI)
+		nestedPrefix := prefix
+		if !ok && opts.has(TagOptionPrefix) && fieldTypeI.PkgPath == "" {
+			//子结构体slice字段只在ScanGrouped中按前缀填充
+			if _, _, isGroup := groupElemType(fieldTypeI.Type); isGroup {
+				continue
+			}
+			nestedType, ptr, ok = structType(fieldTypeI.Type)
+			nestedPrefix = prefix + tagName
+		}
+		if ok {
+			if visited[nestedType] {
+				continue
+			}
+			nested := s.buildFields(nestedType, nestedPrefix, visited)
+			if len(nested) > 0 {
+				fields = append(fields, fieldInfo{index: i, name: fieldTypeI.Name, nested: nested, ptr: ptr})
+			}
+			continue
+		}
+		//未导出字段无法设值
+		if fieldTypeI.PkgPath != "" {
+			continue
+		}
+		column, auto := s.fieldColumn(fieldTypeI)
+		if column == "" {
+			continue
+		}
+		field := fieldInfo{index: i, name: fieldTypeI.Name, auto: auto, mapped: !tagged && s.Mapper != nil, opts: opts, traits: typeTraitsOf(fieldTypeI.Type)}
+		for j, name := range strings.Split(column, ColumnSeparator) {
+			if j == 0 {
+				field.column = prefix + name
+			} else {
+				field.alts = append(field.alts, prefix+name)
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+//判断字段是否为需要展开的匿名嵌入结构体（或结构体指针），设置了标签的匿名字段按普通字段处理
+func (s *Scanner) embeddedStruct(field reflect.StructField) (embeddedType reflect.Type, ptr bool, ok bool) {
+	if !field.Anonymous {
+		return nil, false, false
+	}
+	if _, _, exist := s.lookupTag(field); exist {
+		return nil, false, false
+	}
+	embeddedType, ptr, ok = structType(field.Type)
+	//未导出的嵌入结构体指针无法分配，未导出的嵌入结构体值其导出字段仍可设值
+	if ptr && field.PkgPath != "" {
+		return nil, false, false
+	}
+	return embeddedType, ptr, ok
+}
+
+//判断类型是否为可展开字段的结构体或结构体指针，time.Time及实现了sql.Scanner的结构体除外
+func structType(t reflect.Type) (reflect.Type, bool, bool) {
+	ptr := false
+	if t.Kind() == reflect.Ptr {
+		t, ptr = t.Elem(), true
+	}
+	if t.Kind() != reflect.Struct || t == timeType || reflect.PtrTo(t).Implements(sqlScannerType) {
+		return nil, false, false
+	}
+	return t, ptr, true
+}
+
+//去掉类型的所有指针层级
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+//获取目标（或slice元素）对应的结构体类型
+func targetStructType(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	structObj, _, ok := structType(t)
+	return structObj, ok
+}
+
+//预览列与结构体字段的对应关系，返回列名 -> 字段名，子结构体字段以"."连接，如Audit.CreatedBy
+//target可以是结构体、结构体指针或结构体slice，未匹配到字段的列不出现在结果中
+func MapColumns(target interface{}, columns []string) (map[string]string, error) {
+	return defaultScanner.MapColumns(target, columns)
+}
+
+//使用扫描器的配置预览列与结构体字段的对应关系，匹配规则与扫描一致
+func (s *Scanner) MapColumns(target interface{}, columns []string) (map[string]string, error) {
+	if target == nil {
+		return nil, ErrNotStruct
+	}
+	typeObj, ok := targetStructType(reflect.TypeOf(target))
+	if !ok {
+		return nil, ErrNotStruct
+	}
+	mapping := make(map[string]string, len(columns))
+	mapFieldColumns(s.newColumnSet(columns), s.structFields(typeObj), "", mapping)
+	return mapping, nil
+}
+
+func mapFieldColumns(columns *columnSet, fields []fieldInfo, path string, mapping map[string]string) {
+	for _, field := range fields {
+		if field.extra {
+			continue
+		}
+		if field.nested != nil {
+			mapFieldColumns(columns, field.nested, path+field.name+".", mapping)
+			continue
+		}
+		column, ok := columns.match(field)
+		if _, exist := mapping[column]; ok && !exist {
+			mapping[column] = path + field.name
+		}
+	}
+}
+
+//按标签名称顺序查找字段标签，返回第一个非空标签的名称部分及","之后的选项
+func (s *Scanner) lookupTag(field reflect.StructField) (string, tagOptions, bool) {
+	for _, tag := range s.tagNames() {
+		tagName, opts := parseTag(field.Tag.Get(tag))
+		if tagName != "" {
+			return tagName, opts, true
+		}
+	}
+	return "", "", false
+}
+
+//是否为设置了extra选项的map[string]interface{}字段，如Extra map[string]interface{} `pg:",extra"`
+func (s *Scanner) isExtraField(field reflect.StructField) bool {
+	if field.Type != mapInterfaceType {
+		return false
+	}
+	for _, tag := range s.tagNames() {
+		if _, opts := parseTag(field.Tag.Get(tag)); opts.has(TagOptionExtra) {
+			return true
+		}
+	}
+	return false
+}
+
+//字段列表中是否有extra字段
+func hasExtraField(fields []fieldInfo) bool {
+	for _, field := range fields {
+		if field.extra {
+			return true
+		}
+	}
+	return false
+}
+
+//获取字段对应的列名，auto表示列名是否由字段名自动推导；返回空列名表示跳过该字段
+//标签为"-"的字段始终跳过；未设置标签时依次按Mapper、AutoMap、MatchFieldName推导列名
+func (s *Scanner) fieldColumn(field reflect.StructField) (column string, auto bool) {
+	if tagName, _, ok := s.lookupTag(field); ok {
+		if tagName == SkipTagValue {
+			return "", false
+		}
+		return tagName, false
+	}
+	if s.Mapper != nil {
+		return field.Name, false
+	}
+	if s.AutoMap {
+		return toSnakeCase(field.Name), true
+	}
+	if s.MatchFieldName {
+		return field.Name, false
+	}
+	return "", false
+}
+
+//标签中","之后的选项
+type tagOptions string
+
+//拆分标签的名称与选项
+func parseTag(tag string) (string, tagOptions) {
+	if idx := strings.IndexByte(tag, ','); idx >= 0 {
+		return tag[:idx], tagOptions(tag[idx+1:])
+	}
+	return tag, ""
+}
+
+//是否包含指定选项
+func (o tagOptions) has(name string) bool {
+	for opts := string(o); opts != ""; {
+		var opt string
+		opt, opts = nextTagOption(opts)
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+//获取key=value形式选项的值
+func (o tagOptions) value(key string) (string, bool) {
+	for opts := string(o); opts != ""; {
+		var opt string
+		opt, opts = nextTagOption(opts)
+		if strings.HasPrefix(opt, key+"=") {
+			return opt[len(key)+1:], true
+		}
+	}
+	return "", false
+}
+
+func nextTagOption(opts string) (opt, rest string) {
+	if idx := strings.IndexByte(opts, ','); idx >= 0 {
+		return opts[:idx], opts[idx+1:]
+	}
+	return opts, ""
+}
+
+//结果集的列名索引，扫描、StrictColumns、MapColumns及统计共用同一套匹配规则
+//精确匹配优先，忽略大小写时全小写的列优先，其余情况取列顺序中靠前的列
+type columnSet struct {
+	names  map[string]string //列名 -> 列名，表名限定的索引中为去掉表名后的列名 -> 列名
+	folded map[string]string //小写列名 -> 列名
+	mapped map[string]string //Mapper转换后的列名 -> 列名
+	suffix *columnSet        //StripTableQualifier模式下去掉表名限定后的列名 -> 列名，精确匹配失败后查找
+	fold   bool              //标签匹配列名时忽略大小写
+}
+
+//按列顺序构建列名索引，IgnoreColumns中的列不参与匹配
+func (s *Scanner) newColumnSet(columns []string) *columnSet {
+	set := &columnSet{
+		names:  make(map[string]string, len(columns)),
+		folded: make(map[string]string, len(columns)),
+		fold:   s.CaseInsensitive,
+	}
+	if s.Mapper != nil {
+		set.mapped = make(map[string]string, len(columns))
+	}
+	if s.StripTableQualifier {
+		set.suffix = &columnSet{names: make(map[string]string), folded: make(map[string]string)}
+	}
+	for _, column := range columns {
+		if containsKey(set.names, column) || containsString(s.IgnoreColumns, column) {
+			continue
+		}
+		set.add(column, column)
+		if idx := strings.LastIndexByte(column, '.'); idx >= 0 && set.suffix != nil {
+			set.suffix.add(column[idx+1:], column)
+		}
+		if set.mapped != nil {
+			if name := s.Mapper(column); !containsKey(set.mapped, name) {
+				set.mapped[name] = column
+			}
+		}
+	}
+	return set
+}
+
+//加入列名索引，同名时保留先加入的列
+func (c *columnSet) add(name, column string) {
+	if lower := strings.ToLower(name); !containsKey(c.folded, lower) {
+		c.folded[lower] = column
+	}
+	if !containsKey(c.names, name) {
+		c.names[name] = column
+	}
+}
+
+func containsKey(mp map[string]string, key string) bool {
+	_, ok := mp[key]
+	return ok
+}
+
+//返回按结果集列顺序匹配字段的扫描器副本
+func (s *Scanner) withColumns(columns []string) *Scanner {
+	scanner := *s
+	scanner.columns = s.newColumnSet(columns)
+	return &scanner
+}
+
+//行数据的列名索引，未指定结果集的列顺序时按列名排序，保证匹配结果确定
+func (s *Scanner) columnsOf(result map[string]interface{}) *columnSet {
+	if s.columns != nil {
+		return s.columns
+	}
+	return s.newColumnSet(sortedColumns(result))
+}
+
+//按列名排序的行数据列名
+func sortedColumns(result map[string]interface{}) []string {
+	columns := make([]string, 0, len(result))
+	for column := range result {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+//查找字段的列及候选列中第一个存在的列名
+func (c *columnSet) match(field fieldInfo) (string, bool) {
+	if field.mapped {
+		column, ok := c.mapped[field.column]
+		return column, ok
+	}
+	fold := field.auto || c.fold
+	if column, ok := c.find(field.column, fold); ok {
+		return column, true
+	}
+	for _, alt := range field.alts {
+		if column, ok := c.find(alt, fold); ok {
+			return column, true
+		}
+	}
+	if c.suffix != nil {
+		return c.suffix.match(fieldInfo{column: field.column, alts: field.alts, auto: fold})
+	}
+	return "", false
+}
+
+//查找列名，精确匹配优先，fold为true时再忽略大小写查找
+func (c *columnSet) find(column string, fold bool) (string, bool) {
+	if name, ok := c.names[column]; ok || !fold {
+		return name, ok
+	}
+	lower := strings.ToLower(column)
+	if name, ok := c.names[lower]; ok {
+		return name, true
+	}
+	name, ok := c.folded[lower]
+	return name, ok
+}
+
+//单行结果的列查找
+type columnLookup struct {
+	result  map[string]interface{}
+	columns *columnSet
+}
+
+//返回字段匹配到的列值及实际匹配的列名
+func (l *columnLookup) getField(field fieldInfo) (interface{}, string, bool) {
+	column, ok := l.columns.match(field)
+	if !ok {
+		return nil, "", false
+	}
+	value, ok := l.result[column]
+	return value, column, ok
+}
+
+//驼峰命名转为snake_case，连续大写视为一个单词，如UserID -> user_id，HTTPServer -> http_server
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var builder strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					builder.WriteByte('_')
+				}
+			}
+			builder.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,551 @@
+package db_scan
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+func TestToSnakeCase(t *testing.T) {
+	testCases := map[string]string{
+		"UserID":     "user_id",
+		"ID":         "id",
+		"Name":       "name",
+		"HTTPServer": "http_server",
+		"Address2":   "address2",
+		"CreatedAt":  "created_at",
+	}
+	for name, expect := range testCases {
+		Assert(t, toSnakeCase(name), Equal(expect))
+	}
+}
+
+func TestScannerAutoMap(t *testing.T) {
+	type User struct {
+		UserID   int64
+		UserName string
+		Nick     string `pg:"nickname"`
+		ignore   string
+	}
+	mp := map[string]interface{}{
+		"user_id":   int64(7),
+		"USER_NAME": []byte("tencent"),
+		"nick":      []byte("wrong"),
+		"nickname":  []byte("tc"),
+		"ignore":    []byte("x"),
+	}
+	var u User
+	err := (&Scanner{AutoMap: true}).singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.UserID, Equal(int64(7)))
+	Assert(t, u.UserName, Equal("tencent"))
+	Assert(t, u.Nick, Equal("tc"))
+	Assert(t, u.ignore, Equal(""))
+
+	u = User{}
+	err = defaultScanner.singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.UserID, Equal(int64(0)))
+	Assert(t, u.Nick, Equal("tc"))
+}
+
+func TestScannerCaseInsensitive(t *testing.T) {
+	type User struct {
+		UserName string `pg:"UserName"`
+		Email    string `pg:"EMAIL"`
+		Phone    string `pg:"phone"`
+	}
+	mp := map[string]interface{}{
+		"username": []byte("tencent"),
+		"email":    []byte("a@b.c"),
+		"Email":    []byte("wrong"),
+		"PHONE":    []byte("wrong"),
+		"phone":    []byte("123"),
+	}
+	var u User
+	err := (&Scanner{CaseInsensitive: true}).singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.UserName, Equal("tencent"))
+	Assert(t, u.Email, Equal("a@b.c"))
+	Assert(t, u.Phone, Equal("123"))
+
+	u = User{}
+	err = defaultScanner.singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.UserName, Equal(""))
+	Assert(t, u.Phone, Equal("123"))
+}
+
+type timestamps struct {
+	CreatedAt time.Time `pg:"created_at"`
+	UpdatedAt time.Time `pg:"updated_at"`
+}
+
+type BaseModel struct {
+	ID int64 `pg:"id"`
+}
+
+type SoftDelete struct {
+	DeletedAt time.Time `pg:"deleted_at"`
+}
+
+func TestScannerCaseInsensitiveColumnOrder(t *testing.T) {
+	type User struct {
+		UserName string `pg:"username"`
+	}
+	s := &Scanner{CaseInsensitive: true, StrictColumns: true}
+	for _, columns := range [][]string{{"UserName", "USERNAME"}, {"USERNAME", "UserName"}} {
+		//仅大小写不同的列取列顺序中靠前的列，扫描与预览的结果一致
+		for i := 0; i < 20; i++ {
+			var u User
+			err := s.Scan(newMockRows(columns, []interface{}{[]byte("a"), []byte("b")}), &u)
+			Assert(t, errors.Is(err, ErrUnmappedColumn), Equal(true))
+			Assert(t, err.Error(), Equal(ErrUnmappedColumn.Error()+": "+columns[1]))
+
+			var users []User
+			err = (&Scanner{CaseInsensitive: true}).Scan(newMockRows(columns, []interface{}{[]byte("a"), []byte("b")}), &users)
+			Assert(t, err, NilVal())
+			Assert(t, users, Equal([]User{{UserName: "a"}}))
+		}
+		mapping, err := s.MapColumns(User{}, columns)
+		Assert(t, err, NilVal())
+		Assert(t, mapping, Equal(map[string]string{columns[0]: "UserName"}))
+	}
+}
+
+func TestScannerEmbeddedStruct(t *testing.T) {
+	type User struct {
+		BaseModel
+		timestamps
+		*SoftDelete
+		Name string `pg:"name"`
+	}
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	mp := map[string]interface{}{
+		"id":         int64(1),
+		"name":       []byte("tencent"),
+		"created_at": created,
+		"updated_at": updated,
+	}
+	var u User
+	err := defaultScanner.singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.ID, Equal(int64(1)))
+	Assert(t, u.Name, Equal("tencent"))
+	Assert(t, u.CreatedAt, Equal(created))
+	Assert(t, u.UpdatedAt, Equal(updated))
+	Assert(t, u.SoftDelete == nil, Equal(true))
+
+	mp["deleted_at"] = updated
+	err = defaultScanner.singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.SoftDelete == nil, Equal(false))
+	Assert(t, u.DeletedAt, Equal(updated))
+}
+
+type auditInfo struct {
+	Operator string `pg:"operator"`
+	secret   string `pg:"secret"`
+}
+
+type hiddenInfo struct {
+	Value string `pg:"hidden_value"`
+}
+
+func TestScannerUnexportedFields(t *testing.T) {
+	type Record struct {
+		auditInfo
+		*hiddenInfo
+		ID      int64      `pg:"id"`
+		name    string     `pg:"name"`
+		owner   auditInfo  `pg:"owner_,prefix"`
+		extra   *auditInfo `pg:"extra_,prefix"`
+		counter int
+	}
+	mp := map[string]interface{}{
+		"id":             int64(1),
+		"name":           []byte("n"),
+		"operator":       []byte("op"),
+		"secret":         []byte("s"),
+		"hidden_value":   []byte("h"),
+		"owner_operator": []byte("o"),
+		"extra_operator": []byte("e"),
+		"counter":        int64(3),
+	}
+	for _, s := range []*Scanner{defaultScanner, {AutoMap: true}, {CaseInsensitive: true, ContinueOnError: true}} {
+		var r Record
+		err := s.singleResult(mp, &r)
+		Assert(t, err, NilVal())
+		Assert(t, r, Equal(Record{auditInfo: auditInfo{Operator: "op"}, ID: 1}))
+
+		data, err := s.StructToMap(&r)
+		Assert(t, err, NilVal())
+		Assert(t, data, Equal(map[string]interface{}{"operator": "op", "id": int64(1)}))
+
+		mapping, err := s.MapColumns(&r, []string{"id", "name", "operator", "secret", "hidden_value", "counter"})
+		Assert(t, err, NilVal())
+		Assert(t, mapping, Equal(map[string]string{"id": "ID", "operator": "auditInfo.Operator"}))
+	}
+}
+
+func TestScannerSkipTag(t *testing.T) {
+	type User struct {
+		BaseModel `pg:"-"`
+		Name      string `pg:"name"`
+		Display   string `pg:"-"`
+		Nick      string `pg:"-"`
+	}
+	mp := map[string]interface{}{
+		"id":      int64(1),
+		"name":    []byte("tencent"),
+		"display": []byte("x"),
+		"-":       []byte("x"),
+		"nick":    []byte("x"),
+	}
+	var u User
+	err := (&Scanner{AutoMap: true}).singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{Name: "tencent"}))
+}
+
+func TestScannerTagNames(t *testing.T) {
+	type User struct {
+		ID     int64  `pg:"user_id" json:"id"`
+		Name   string `json:"name,omitempty"`
+		Email  string `pg:"" json:"mail"`
+		Secret string `json:"-"`
+		Nick   string
+	}
+	mp := map[string]interface{}{
+		"user_id": int64(1),
+		"id":      int64(2),
+		"name":    []byte("tencent"),
+		"mail":    []byte("a@b.c"),
+		"-":       []byte("x"),
+		"Secret":  []byte("x"),
+		"nick":    []byte("x"),
+	}
+	var u User
+	err := (&Scanner{TagNames: []string{"pg", "json"}}).singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{ID: 1, Name: "tencent", Email: "a@b.c"}))
+}
+
+func TestScannerPrefixNested(t *testing.T) {
+	type Address struct {
+		City string `pg:"city"`
+		Zip  string `pg:"zip"`
+	}
+	type User struct {
+		ID   int64    `pg:"id"`
+		Name string   `pg:"name"`
+		Addr *Address `pg:"addr_,prefix"`
+	}
+	type Order struct {
+		ID      int64   `pg:"id"`
+		User    User    `pg:"user_,prefix"`
+		Billing Address `pg:"bill_,prefix"`
+	}
+	mp := map[string]interface{}{
+		"id":             int64(100),
+		"user_id":        int64(1),
+		"user_name":      []byte("tencent"),
+		"user_addr_city": []byte("shenzhen"),
+		"bill_city":      []byte("beijing"),
+		"bill_zip":       []byte("100000"),
+		"city":           []byte("wrong"),
+	}
+	var o Order
+	err := defaultScanner.singleResult(mp, &o)
+	Assert(t, err, NilVal())
+	Assert(t, o.ID, Equal(int64(100)))
+	Assert(t, o.User.ID, Equal(int64(1)))
+	Assert(t, o.User.Name, Equal("tencent"))
+	Assert(t, *o.User.Addr, Equal(Address{City: "shenzhen"}))
+	Assert(t, o.Billing, Equal(Address{City: "beijing", Zip: "100000"}))
+
+	delete(mp, "user_addr_city")
+	o = Order{}
+	err = defaultScanner.singleResult(mp, &o)
+	Assert(t, err, NilVal())
+	Assert(t, o.User.Addr == nil, Equal(true))
+}
+
+func TestScannerNestedPtrAllNull(t *testing.T) {
+	type Dept struct {
+		ID     int64  `pg:"id"`
+		Name   string `pg:"name"`
+		Status string `pg:"status,default=active"`
+	}
+	type User struct {
+		ID   int64 `pg:"id"`
+		Dept *Dept `pg:"dept_,prefix"`
+		*SoftDelete
+	}
+	mp := map[string]interface{}{
+		"id":         int64(1),
+		"dept_id":    nil,
+		"dept_name":  nil,
+		"deleted_at": nil,
+	}
+	var u User
+	err := defaultScanner.singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.ID, Equal(int64(1)))
+	Assert(t, u.Dept == nil, Equal(true))
+	Assert(t, u.SoftDelete == nil, Equal(true))
+
+	err = (&Scanner{NullLiterals: []string{"NULL"}}).singleResult(map[string]interface{}{"dept_id": []byte("NULL")}, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.Dept == nil, Equal(true))
+
+	mp["dept_name"] = []byte("rd")
+	err = defaultScanner.singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, *u.Dept, Equal(Dept{Name: "rd", Status: "active"}))
+}
+
+func TestScannerAlternativeColumns(t *testing.T) {
+	type Audit struct {
+		By string `pg:"by|operator"`
+	}
+	type Event struct {
+		ID        int64     `pg:"id|event_id"`
+		CreatedAt time.Time `pg:"created_at|creation_time"`
+		Audit     Audit     `pg:"audit_,prefix"`
+	}
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var e Event
+	err := defaultScanner.singleResult(map[string]interface{}{
+		"event_id":       int64(7),
+		"creation_time":  created,
+		"audit_operator": []byte("tc"),
+	}, &e)
+	Assert(t, err, NilVal())
+	Assert(t, e, Equal(Event{ID: 7, CreatedAt: created, Audit: Audit{By: "tc"}}))
+
+	//多个候选列同时存在时取第一个
+	e = Event{}
+	err = defaultScanner.singleResult(map[string]interface{}{"id": int64(1), "event_id": int64(2)}, &e)
+	Assert(t, err, NilVal())
+	Assert(t, e.ID, Equal(int64(1)))
+
+	var list []Event
+	err = (&Scanner{StrictColumns: true}).Scan(newMockRows([]string{"event_id", "creation_time", "audit_by"},
+		[]interface{}{int64(3), created, []byte("x")}), &list)
+	Assert(t, err, NilVal())
+	Assert(t, list[0].ID, Equal(int64(3)))
+
+	mapping, err := MapColumns(&e, []string{"creation_time", "audit_operator"})
+	Assert(t, err, NilVal())
+	Assert(t, mapping, Equal(map[string]string{"creation_time": "CreatedAt", "audit_operator": "Audit.By"}))
+}
+
+func TestScannerMapper(t *testing.T) {
+	type User struct {
+		ID       int64
+		UserName string
+		Email    string `pg:"mail"`
+		Ignore   string `pg:"-"`
+	}
+	//去掉表名前缀后转为驼峰命名
+	s := &Scanner{Mapper: func(column string) string {
+		column = strings.TrimPrefix(column, "t_user_")
+		var builder strings.Builder
+		for _, part := range strings.Split(column, "_") {
+			if part == "id" {
+				builder.WriteString("ID")
+				continue
+			}
+			if part != "" {
+				builder.WriteString(strings.ToUpper(part[:1]) + part[1:])
+			}
+		}
+		return builder.String()
+	}}
+	rows := newMockRows([]string{"t_user_id", "t_user_user_name", "mail", "t_user_ignore"},
+		[]interface{}{int64(1), []byte("tencent"), []byte("a@b.c"), []byte("x")})
+	var list []User
+	err := s.Scan(rows, &list)
+	Assert(t, err, NilVal())
+	Assert(t, list, Equal([]User{{ID: 1, UserName: "tencent", Email: "a@b.c"}}))
+
+	mapping, err := s.MapColumns(&User{}, []string{"t_user_id", "t_user_user_name", "other"})
+	Assert(t, err, NilVal())
+	Assert(t, mapping, Equal(map[string]string{"t_user_id": "ID", "t_user_user_name": "UserName"}))
+
+	//未设置Mapper时未设置标签的字段不参与扫描
+	var u User
+	err = defaultScanner.singleResult(map[string]interface{}{"t_user_id": int64(1), "ID": int64(2), "mail": []byte("m")}, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{Email: "m"}))
+}
+
+func TestScannerMatchFieldName(t *testing.T) {
+	type User struct {
+		ID    int64
+		Name  string
+		Email string `pg:"mail"`
+		Nick  string `pg:"-"`
+	}
+	mp := map[string]interface{}{
+		"ID":   int64(1),
+		"Name": []byte("tencent"),
+		"mail": []byte("a@b.c"),
+		"Nick": []byte("x"),
+	}
+	var u User
+	err := (&Scanner{MatchFieldName: true}).singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{ID: 1, Name: "tencent", Email: "a@b.c"}))
+
+	//只匹配完全相同的列名
+	u = User{}
+	err = (&Scanner{MatchFieldName: true}).singleResult(map[string]interface{}{"name": []byte("x"), "id": int64(2)}, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{}))
+
+	//AutoMap优先
+	err = (&Scanner{MatchFieldName: true, AutoMap: true}).singleResult(map[string]interface{}{"name": []byte("auto")}, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.Name, Equal("auto"))
+}
+
+func TestScannerStripTableQualifier(t *testing.T) {
+	type User struct {
+		ID   int64  `pg:"id"`
+		Name string `pg:"name"`
+		Mail string `pg:"email|mail"`
+	}
+	mp := map[string]interface{}{
+		"users.id":   int64(1),
+		"users.name": []byte("tencent"),
+		"u.mail":     []byte("a@b.c"),
+	}
+	var u User
+	err := (&Scanner{StripTableQualifier: true}).singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{ID: 1, Name: "tencent", Mail: "a@b.c"}))
+
+	//默认不去掉表名限定
+	u = User{}
+	err = defaultScanner.singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{}))
+
+	//精确匹配优先
+	mp["name"] = []byte("exact")
+	err = (&Scanner{StripTableQualifier: true}).singleResult(mp, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.Name, Equal("exact"))
+
+	//大小写不敏感时同样生效
+	u = User{}
+	err = (&Scanner{StripTableQualifier: true, CaseInsensitive: true}).singleResult(map[string]interface{}{"Users.ID": int64(2)}, &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.ID, Equal(int64(2)))
+
+	mapping, err := (&Scanner{StripTableQualifier: true}).MapColumns(User{}, []string{"users.id", "users.name", "id2"})
+	Assert(t, err, NilVal())
+	Assert(t, mapping, Equal(map[string]string{"users.id": "ID", "users.name": "Name"}))
+
+	//多个表的同名列取列顺序中靠前的列，扫描、预览及统计的结果一致
+	columns := []string{"users.id", "orders.id", "users.name", "u.mail"}
+	row := []interface{}{int64(1), int64(2), []byte("a"), []byte("m")}
+	s := &Scanner{StripTableQualifier: true}
+	for i := 0; i < 20; i++ {
+		u = User{}
+		stats, err := s.ScanWithStats(newMockRows(columns, row), &u)
+		Assert(t, err, NilVal())
+		Assert(t, u.ID, Equal(int64(1)))
+		Assert(t, stats.ColumnsUnmatched, Equal(1))
+	}
+	mapping, err = s.MapColumns(User{}, columns)
+	Assert(t, err, NilVal())
+	Assert(t, mapping["users.id"], Equal("ID"))
+	Assert(t, len(mapping), Equal(3))
+	err = (&Scanner{StripTableQualifier: true, StrictColumns: true}).Scan(newMockRows(columns, row), &u)
+	Assert(t, err.Error(), Equal(ErrUnmappedColumn.Error()+": orders.id"))
+
+	u = User{}
+	err = s.Scan(newMockRows([]string{"orders.id", "users.id"}, []interface{}{int64(2), int64(1)}), &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.ID, Equal(int64(2)))
+}
+
+func TestMapColumns(t *testing.T) {
+	type Address struct {
+		City string `pg:"city"`
+	}
+	type User struct {
+		BaseModel
+		Name    string   `pg:"name"`
+		Address *Address `pg:"addr_,prefix"`
+	}
+	columns := []string{"id", "NAME", "addr_city", "unknown"}
+	mapping, err := MapColumns(&User{}, columns)
+	Assert(t, err, NilVal())
+	Assert(t, mapping, Equal(map[string]string{"id": "BaseModel.ID", "addr_city": "Address.City"}))
+
+	mapping, err = (&Scanner{CaseInsensitive: true}).MapColumns([]*User{}, columns)
+	Assert(t, err, NilVal())
+	Assert(t, mapping["NAME"], Equal("Name"))
+	Assert(t, len(mapping), Equal(3))
+
+	type Profile struct {
+		NickName string
+	}
+	mapping, err = (&Scanner{AutoMap: true}).MapColumns(Profile{}, []string{"nick_name"})
+	Assert(t, err, NilVal())
+	Assert(t, mapping, Equal(map[string]string{"nick_name": "NickName"}))
+
+	_, err = MapColumns(new(int), columns)
+	Assert(t, err, Equal(ErrNotStruct))
+	_, err = MapColumns(nil, columns)
+	Assert(t, err, Equal(ErrNotStruct))
+}
+
+func TestScannerExtraField(t *testing.T) {
+	type Product struct {
+		ID    int64                  `pg:"id"`
+		Name  string                 `pg:"name"`
+		Extra map[string]interface{} `pg:",extra"`
+	}
+	mp := map[string]interface{}{"id": int64(1), "name": []byte("pen"), "color": []byte("red"), "weight": int64(10)}
+	var p Product
+	err := defaultScanner.singleResult(mp, &p)
+	Assert(t, err, NilVal())
+	Assert(t, p.ID, Equal(int64(1)))
+	Assert(t, p.Name, Equal("pen"))
+	Assert(t, p.Extra, Equal(map[string]interface{}{"color": []byte("red"), "weight": int64(10)}))
+
+	err = defaultScanner.singleResult(map[string]interface{}{"id": int64(2)}, &p)
+	Assert(t, err, NilVal())
+	Assert(t, p.Extra == nil, Equal(true))
+
+	//StrictColumns模式下未匹配的列由extra字段接收，不视为错误
+	var list []Product
+	err = (&Scanner{StrictColumns: true}).Scan(newMockRows([]string{"id", "name", "color"}, []interface{}{int64(3), []byte("ink"), nil}), &list)
+	Assert(t, err, NilVal())
+	Assert(t, list[0].Extra, Equal(map[string]interface{}{"color": nil}))
+
+	data, err := StructToMap(&p)
+	Assert(t, err, NilVal())
+	Assert(t, data, Equal(map[string]interface{}{"id": int64(2), "name": "pen"}))
+}
+
+func TestTagOptions(t *testing.T) {
+	name, opts := parseTag("status,default=active,prefix")
+	Assert(t, name, Equal("status"))
+	Assert(t, opts.has("prefix"), Equal(true))
+	Assert(t, opts.has("default"), Equal(false))
+	value, ok := opts.value("default")
+	Assert(t, ok, Equal(true))
+	Assert(t, value, Equal("active"))
+	_, ok = opts.value("base")
+	Assert(t, ok, Equal(false))
+}
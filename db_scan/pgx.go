@@ -0,0 +1,87 @@
+package db_scan
+
+import (
+	"errors"
+	"reflect"
+)
+
+var (
+	ErrPgxScanDest = errors.New("pgx适配器仅支持*interface{}类型的接收参数")
+	ErrPgxColumns  = errors.New("无法通过FieldDescriptions获取列名")
+)
+
+//适配pgx.Rows所需的最小方法集，pgx v4/v5的pgx.Rows均满足该接口
+//列名通过pgx.Rows的FieldDescriptions()方法获取，其元素需包含Name字段（string或[]byte）
+type PgxRows interface {
+	Close()
+	Err() error
+	Next() bool
+	Values() ([]interface{}, error)
+}
+
+//将pgx.Rows包装为IRows，不引入pgx依赖；Close返回迭代过程中的错误(Err())
+//列值为pgx解码后的Go类型，不支持ScanEachRaw
+func FromPgxRows(r PgxRows) IRows {
+	return &pgxRowsAdapter{rows: r}
+}
+
+type pgxRowsAdapter struct {
+	rows PgxRows
+}
+
+func (a *pgxRowsAdapter) Close() error {
+	a.rows.Close()
+	return a.rows.Err()
+}
+
+func (a *pgxRowsAdapter) Columns() ([]string, error) {
+	method := reflect.ValueOf(a.rows).MethodByName("FieldDescriptions")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil, ErrPgxColumns
+	}
+	descriptions := method.Call(nil)[0]
+	if descriptions.Kind() != reflect.Slice {
+		return nil, ErrPgxColumns
+	}
+	columns := make([]string, descriptions.Len())
+	for i := range columns {
+		desc := reflect.Indirect(descriptions.Index(i))
+		if desc.Kind() != reflect.Struct {
+			return nil, ErrPgxColumns
+		}
+		name := desc.FieldByName("Name")
+		switch {
+		case !name.IsValid():
+			return nil, ErrPgxColumns
+		case name.Kind() == reflect.String:
+			columns[i] = name.String()
+		case isBytesType(name.Type()):
+			columns[i] = string(name.Bytes())
+		default:
+			return nil, ErrPgxColumns
+		}
+	}
+	return columns, nil
+}
+
+func (a *pgxRowsAdapter) Next() bool {
+	return a.rows.Next()
+}
+
+func (a *pgxRowsAdapter) Scan(dest ...interface{}) error {
+	values, err := a.rows.Values()
+	if nil != err {
+		return err
+	}
+	for i, value := range values {
+		if i >= len(dest) {
+			break
+		}
+		target, ok := dest[i].(*interface{})
+		if !ok {
+			return ErrPgxScanDest
+		}
+		*target = value
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package db_scan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+//模拟pgx v5的FieldDescription
+type fakeFieldDescription struct {
+	Name string
+	OID  uint32
+}
+
+//模拟pgx.Rows，Values返回pgx解码后的Go值
+type fakePgxRows struct {
+	fields []fakeFieldDescription
+	data   [][]interface{}
+	cursor int
+	closed bool
+	err    error
+}
+
+func (r *fakePgxRows) Close()     { r.closed = true }
+func (r *fakePgxRows) Err() error { return r.err }
+
+func (r *fakePgxRows) Next() bool {
+	r.cursor++
+	return r.cursor < len(r.data)
+}
+
+func (r *fakePgxRows) Values() ([]interface{}, error) {
+	return r.data[r.cursor], nil
+}
+
+func (r *fakePgxRows) FieldDescriptions() []fakeFieldDescription {
+	return r.fields
+}
+
+func TestFromPgxRows(t *testing.T) {
+	type User struct {
+		ID        int32     `pg:"id"`
+		Name      string    `pg:"name"`
+		CreatedAt time.Time `pg:"created_at"`
+	}
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	pgxRows := &fakePgxRows{
+		fields: []fakeFieldDescription{{Name: "id"}, {Name: "name"}, {Name: "created_at"}},
+		data:   [][]interface{}{{int32(1), "alice", now}, {int32(2), "bob", now}},
+		cursor: -1,
+	}
+	var users []User
+	err := Scan(FromPgxRows(pgxRows), &users)
+	Assert(t, err, NilVal())
+	Assert(t, users, Equal([]User{{ID: 1, Name: "alice", CreatedAt: now}, {ID: 2, Name: "bob", CreatedAt: now}}))
+	Assert(t, pgxRows.closed, Equal(true))
+
+	iterErr := errors.New("conn reset")
+	pgxRows = &fakePgxRows{fields: []fakeFieldDescription{{Name: "id"}}, cursor: -1, err: iterErr}
+	err = Scan(FromPgxRows(pgxRows), &users)
+	Assert(t, err, Equal(iterErr))
+}
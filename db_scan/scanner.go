@@ -0,0 +1,135 @@
+package db_scan
+
+//列名到字段名的转换函数，如去掉表名前缀后转为驼峰命名
+type Mapper func(column string) string
+
+//每行转换前调用的回调，index为行下标，返回错误时中止扫描
+type RowHook func(index int, row map[string]interface{}) error
+
+//数据集扫描器，用于定制扫描行为
+type Scanner struct {
+	TagName             string                   //结构体标签名称，为空时使用DefaultTagName
+	TagNames            []string                 //按顺序尝试的标签名称，取第一个非空的标签，设置时优先于TagName
+	TimeFormats         []string                 //时间格式，解析时按顺序尝试，格式化时使用第一个；为空时使用DefaultTimeFormat
+	AutoMap             bool                     //未设置标签的导出字段按字段名的snake_case形式匹配列（忽略大小写），标签为"-"的字段仍跳过
+	Mapper              Mapper                   //未设置标签的导出字段按Mapper转换后的列名匹配字段名，优先于AutoMap；未设置时不生效
+	MatchFieldName      bool                     //未设置标签的导出字段匹配与字段名完全相同的列，如字段Name匹配列Name；AutoMap优先
+	StripTableQualifier bool                     //列名带有表名限定（如users.id）时，精确匹配失败后按最后一个"."之后的部分匹配；多个表的同名列取列顺序中靠前的列
+	CaseInsensitive     bool                     //标签匹配列名时忽略大小写，大小写完全一致的列优先，其次为全小写的列，其余取列顺序中靠前的列
+	ContinueOnError     bool                     //转换失败时继续扫描，保留成功转换的行与字段，最终返回*MultiError
+	SkipScanErrors      bool                     //rows.Scan出错时跳过该行继续读取，返回*SkippedRowsError；仅Scan、ScanN等一次性提取数据的扫描生效，默认出错即返回
+	StrictColumns       bool                     //按首行校验列与结构体字段一一对应，字段缺少列时返回ErrMissingColumn，列没有对应字段时返回ErrUnmappedColumn
+	BytesToString       bool                     //interface{}字段接收[]byte值时转为string，否则interface{}字段保存驱动返回的原始值
+	ReuseBuffers        bool                     //在多次扫描之间复用临时缓冲区以减少内存分配，开启后扫描器不能并发使用
+	ReuseSlice          bool                     //slice目标的容量不小于行数时复用其底层数组，此前引用该数组的slice会被覆盖
+	AllowEmpty          bool                     //非slice目标没有数据时保持目标不变并返回nil，默认返回ErrEmptyResult；ScanOne不受影响
+	SingleStrict        bool                     //非slice目标的结果多于一行时返回ErrMultipleResults，默认取第一行
+	NullLiterals        []string                 //视为NULL的文本值（区分大小写），如"NULL"、"null"，默认不处理
+	IgnoreColumns       []string                 //提取数据后丢弃的列，即使有字段匹配也保持零值，如SELECT *中的大字段
+	TreatEmptyAsZero    bool                     //空文本值转换到数值、bool字段时设为零值，默认返回ErrConvertValue
+	DuplicateColumns    DuplicatePolicy          //结果集中出现重复列名时的处理方式，默认保留最后一列
+	InitEmptySlice      bool                     //slice目标没有数据时设为长度为0的非nil slice（JSON序列化为[]），默认保持不变
+	HexDecodeBytes      bool                     //字节slice字段接收以"\x"开头的十六进制文本时解码为二进制数据，如BYTEA的"\x48656c6c6f"
+	ColumnConverters    map[string]ConverterFunc //按列名指定的转换函数，先按实际匹配的列名查找，其次按字段标签的列名查找，优先于RegisterConverter注册的类型转换及内置转换；列为NULL时按内置规则处理，不调用
+	TrimSpace           bool                     //string字段去掉文本值末尾的空格，用于定长CHAR(n)列
+	OnRow               RowHook                  //扫描到slice目标时每行转换前调用，用于统计、采样或调试，返回错误时中止扫描；不应修改row
+
+	buffers    *scanBuffers    //ReuseBuffers模式下复用的缓冲区
+	onlyFields map[string]bool //ScanFields指定的列名，非nil时只为这些列对应的字段赋值
+	stats      *Stats          //ScanWithStats收集的统计信息
+	columns    *columnSet      //当前扫描的结果集列名索引，为nil时按行数据的列名构建
+}
+
+//包级别扫描使用的默认扫描器
+var defaultScanner = &Scanner{TagName: DefaultTagName}
+
+//使用指定的标签名称进行数据集扫描
+func ScanWithTag(rows IRows, target interface{}, tagName string) error {
+	return (&Scanner{TagName: tagName}).Scan(rows, target)
+}
+
+//只为标签（列名或候选列名）在fields中的结构体字段赋值，其余字段即使有对应的列也保持零值
+//子结构体字段按加上前缀后的完整列名匹配
+func ScanFields(rows IRows, target interface{}, fields []string) error {
+	return defaultScanner.ScanFields(rows, target, fields)
+}
+
+//使用扫描器的配置扫描指定的字段
+func (s *Scanner) ScanFields(rows IRows, target interface{}, fields []string) error {
+	scanner := *s
+	scanner.onlyFields = make(map[string]bool, len(fields))
+	for _, field := range fields {
+		scanner.onlyFields[field] = true
+	}
+	return scanner.Scan(rows, target)
+}
+
+//复制扫描器的配置，slice及map类型的配置一并复制，ReuseBuffers的缓冲区不共享
+//字段映射信息缓存按类型及配置区分，副本之间可安全共享
+func (s *Scanner) Clone() *Scanner {
+	clone := *s
+	clone.buffers = nil
+	clone.TagNames = copyStrings(s.TagNames)
+	clone.TimeFormats = copyStrings(s.TimeFormats)
+	clone.NullLiterals = copyStrings(s.NullLiterals)
+	clone.IgnoreColumns = copyStrings(s.IgnoreColumns)
+	if s.ColumnConverters != nil {
+		clone.ColumnConverters = make(map[string]ConverterFunc, len(s.ColumnConverters))
+		for column, fn := range s.ColumnConverters {
+			clone.ColumnConverters[column] = fn
+		}
+	}
+	return &clone
+}
+
+//返回使用指定标签名称的副本，同时清空TagNames使其生效
+func (s *Scanner) WithTag(tagName string) *Scanner {
+	clone := s.Clone()
+	clone.TagName, clone.TagNames = tagName, nil
+	return clone
+}
+
+//返回使用指定时间格式的副本
+func (s *Scanner) WithTimeFormat(layouts ...string) *Scanner {
+	clone := s.Clone()
+	clone.TimeFormats = copyStrings(layouts)
+	return clone
+}
+
+//返回开启AutoMap的副本
+func (s *Scanner) WithAutoMap() *Scanner {
+	clone := s.Clone()
+	clone.AutoMap = true
+	return clone
+}
+
+func copyStrings(list []string) []string {
+	if list == nil {
+		return nil
+	}
+	return append([]string(nil), list...)
+}
+
+//获取实际使用的标签名称
+func (s *Scanner) tagName() string {
+	if s.TagName == "" {
+		return DefaultTagName
+	}
+	return s.TagName
+}
+
+//获取实际使用的标签名称列表
+func (s *Scanner) tagNames() []string {
+	if len(s.TagNames) > 0 {
+		return s.TagNames
+	}
+	return []string{s.tagName()}
+}
+
+//获取实际使用的时间格式列表
+func (s *Scanner) timeFormats() []string {
+	if len(s.TimeFormats) == 0 {
+		return defaultTimeLayouts
+	}
+	return s.TimeFormats
+}
@@ -0,0 +1,878 @@
+package db_scan
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+//模拟的数据集
+type mockRows struct {
+	columns  []string
+	data     [][]interface{}
+	cursor   int
+	closed   bool
+	closes   int
+	closeErr error
+}
+
+func newMockRows(columns []string, data ...[]interface{}) *mockRows {
+	return &mockRows{columns: columns, data: data, cursor: -1}
+}
+
+func (m *mockRows) Close() error {
+	m.closed = true
+	m.closes++
+	return m.closeErr
+}
+
+func (m *mockRows) Columns() ([]string, error) {
+	return m.columns, nil
+}
+
+func (m *mockRows) Next() bool {
+	m.cursor++
+	return m.cursor < len(m.data)
+}
+
+//与database/sql一致：*interface{}接收[]byte时拷贝，*sql.RawBytes接收时引用原始数据或格式化为文本
+func (m *mockRows) Scan(dest ...interface{}) error {
+	for i, v := range m.data[m.cursor] {
+		switch d := dest[i].(type) {
+		case *interface{}:
+			if b, ok := v.([]byte); ok {
+				v = append([]byte(nil), b...)
+			}
+			*d = v
+		case *sql.RawBytes:
+			*d = rawBytes((*d)[:0], v)
+		}
+	}
+	return nil
+}
+
+func rawBytes(buf sql.RawBytes, v interface{}) sql.RawBytes {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return v
+	case string:
+		return append(buf, v...)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case float64:
+		return strconv.AppendFloat(buf, v, 'g', -1, 64)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case time.Time:
+		return v.AppendFormat(buf, time.RFC3339Nano)
+	}
+	return append(buf, fmt.Sprint(v)...)
+}
+
+func TestScanWithTag(t *testing.T) {
+	type Person struct {
+		Name string `db:"name" pg:"pg_name"`
+		Age  int64  `db:"age"`
+	}
+	rows := newMockRows([]string{"name", "age"}, []interface{}{[]byte("tencent"), int64(20)})
+
+	var p *Person
+	err := ScanWithTag(rows, &p, "db")
+	Assert(t, err, NilVal())
+	Assert(t, p.Name, Equal("tencent"))
+	Assert(t, p.Age, Equal(int64(20)))
+}
+
+func TestScannerEmptyTagFallback(t *testing.T) {
+	type Person struct {
+		Name string `pg:"name"`
+	}
+	rows := newMockRows([]string{"name"}, []interface{}{[]byte("a")}, []interface{}{[]byte("b")})
+
+	var persons []Person
+	err := (&Scanner{}).Scan(rows, &persons)
+	Assert(t, err, NilVal())
+	Assert(t, len(persons), Equal(2))
+	Assert(t, persons[1].Name, Equal("b"))
+}
+
+func TestScannerTimeFormats(t *testing.T) {
+	type Event struct {
+		Day     time.Time `pg:"day"`
+		DayText string    `pg:"day_text"`
+	}
+	scanner := &Scanner{TimeFormats: []string{"02/01/2006", time.RFC3339}}
+	day := time.Date(2020, 5, 6, 0, 0, 0, 0, time.UTC)
+	var e Event
+	err := scanner.singleResult(map[string]interface{}{"day": []byte("06/05/2020"), "day_text": day}, &e)
+	Assert(t, err, NilVal())
+	Assert(t, e.Day.Equal(day), Equal(true))
+	Assert(t, e.DayText, Equal("06/05/2020"))
+
+	err = scanner.singleResult(map[string]interface{}{"day": "2020-05-06T00:00:00Z"}, &e)
+	Assert(t, err, NilVal())
+	Assert(t, e.Day.Equal(day), Equal(true))
+
+	err = scanner.singleResult(map[string]interface{}{"day": "2020-05-06 00:00:00"}, &e)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+}
+
+func TestScanPrimitiveSlice(t *testing.T) {
+	rows := newMockRows([]string{"id"}, []interface{}{int64(1)}, []interface{}{int64(2)}, []interface{}{nil})
+	var ids []*int64
+	err := Scan(rows, &ids)
+	Assert(t, err, NilVal())
+	Assert(t, len(ids), Equal(3))
+	Assert(t, *ids[0], Equal(int64(1)))
+	Assert(t, *ids[1], Equal(int64(2)))
+	Assert(t, ids[2] == nil, Equal(true))
+
+	rows = newMockRows([]string{"name"}, []interface{}{[]byte("a")}, []interface{}{[]byte("b")})
+	var names []string
+	err = Scan(rows, &names)
+	Assert(t, err, NilVal())
+	Assert(t, names, Equal([]string{"a", "b"}))
+
+	rows = newMockRows([]string{"id", "name"}, []interface{}{int64(1), []byte("a")})
+	err = Scan(rows, &names)
+	Assert(t, err, Equal(ErrMultipleColumns))
+}
+
+func TestScanMapTarget(t *testing.T) {
+	rows := newMockRows([]string{"id", "name"}, []interface{}{int64(1), []byte("a")}, []interface{}{int64(2), []byte("b")})
+	var row map[string]interface{}
+	err := Scan(rows, &row)
+	Assert(t, err, NilVal())
+	Assert(t, row, Equal(map[string]interface{}{"id": int64(1), "name": []byte("a")}))
+
+	rows = newMockRows([]string{"id", "name"}, []interface{}{int64(1), []byte("a")}, []interface{}{int64(2), []byte("b")})
+	var list []map[string]interface{}
+	err = Scan(rows, &list)
+	Assert(t, err, NilVal())
+	Assert(t, len(list), Equal(2))
+	Assert(t, list[1]["id"], Equal(int64(2)))
+
+	err = Scan(newMockRows([]string{"id"}), &row)
+	Assert(t, err, Equal(ErrEmptyResult))
+
+	list = nil
+	err = Scan(newMockRows([]string{"id"}), &list)
+	Assert(t, err, NilVal())
+	Assert(t, list == nil, Equal(true))
+}
+
+func TestScanTypedMapTarget(t *testing.T) {
+	rows := newMockRows([]string{"host", "port"}, []interface{}{[]byte("localhost"), int64(5432)})
+	var config map[string]string
+	err := Scan(rows, &config)
+	Assert(t, err, NilVal())
+	Assert(t, config, Equal(map[string]string{"host": "localhost", "port": "5432"}))
+
+	rows = newMockRows([]string{"min", "max"}, []interface{}{int64(1), []byte("10")}, []interface{}{int64(2), []byte("20")})
+	var limits []map[string]int
+	err = Scan(rows, &limits)
+	Assert(t, err, NilVal())
+	Assert(t, limits, Equal([]map[string]int{{"min": 1, "max": 10}, {"min": 2, "max": 20}}))
+
+	var counts map[string]int
+	err = Scan(newMockRows([]string{"host"}, []interface{}{[]byte("localhost")}), &counts)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	var byID map[int]string
+	err = Scan(newMockRows([]string{"host"}, []interface{}{[]byte("localhost")}), &byID)
+	Assert(t, errors.Is(err, ErrUnSupportTypeConvert), Equal(true))
+}
+
+func TestScanScalarTarget(t *testing.T) {
+	var count int64
+	err := Scan(newMockRows([]string{"count"}, []interface{}{int64(42)}), &count)
+	Assert(t, err, NilVal())
+	Assert(t, count, Equal(int64(42)))
+
+	var name string
+	err = Scan(newMockRows([]string{"name"}, []interface{}{[]byte("tevid")}), &name)
+	Assert(t, err, NilVal())
+	Assert(t, name, Equal("tevid"))
+
+	var updated *time.Time
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	err = Scan(newMockRows([]string{"max"}, []interface{}{now}), &updated)
+	Assert(t, err, NilVal())
+	Assert(t, *updated, Equal(now))
+
+	err = Scan(newMockRows([]string{"count"}), &count)
+	Assert(t, err, Equal(ErrEmptyResult))
+
+	err = Scan(newMockRows([]string{"id", "name"}, []interface{}{int64(1), []byte("a")}), &count)
+	Assert(t, errors.Is(err, ErrMultipleColumns), Equal(true))
+
+	//实现了sql.Scanner、ColumnUnmarshaler的类型及interface{}按单列值接收
+	var n sql.NullInt64
+	err = Scan(newMockRows([]string{"max"}, []interface{}{int64(7)}), &n)
+	Assert(t, err, NilVal())
+	Assert(t, n, Equal(sql.NullInt64{Int64: 7, Valid: true}))
+	err = Scan(newMockRows([]string{"max"}, []interface{}{nil}), &n)
+	Assert(t, err, NilVal())
+	Assert(t, n, Equal(sql.NullInt64{}))
+
+	var counts []sql.NullInt64
+	err = Scan(newMockRows([]string{"count"}, []interface{}{int64(1)}, []interface{}{nil}), &counts)
+	Assert(t, err, NilVal())
+	Assert(t, counts, Equal([]sql.NullInt64{{Int64: 1, Valid: true}, {}}))
+
+	var value interface{}
+	err = Scan(newMockRows([]string{"v"}, []interface{}{int64(3)}), &value)
+	Assert(t, err, NilVal())
+	Assert(t, value, Equal(interface{}(int64(3))))
+
+	var column columnValue
+	err = Scan(newMockRows([]string{"v"}, []interface{}{[]byte("x")}), &column)
+	Assert(t, err, NilVal())
+	Assert(t, column, Equal(columnValue{Kind: "bytes", Value: "x"}))
+
+	var ch chan int
+	err = Scan(newMockRows([]string{"v"}, []interface{}{int64(3)}), &ch)
+	Assert(t, errors.Is(err, ErrUnSupportTypeConvert), Equal(true))
+}
+
+func TestScannerAllowEmpty(t *testing.T) {
+	type User struct {
+		ID int64 `pg:"id"`
+	}
+	s := &Scanner{AllowEmpty: true}
+	u := User{ID: 7}
+	err := s.Scan(newMockRows([]string{"id"}), &u)
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{ID: 7}))
+
+	var p *User
+	n, err := s.ScanN(newMockRows([]string{"id"}), &p)
+	Assert(t, err, NilVal())
+	Assert(t, n, Equal(0))
+	Assert(t, p == nil, Equal(true))
+
+	err = s.ScanOne(newMockRows([]string{"id"}), &u)
+	Assert(t, err, Equal(ErrEmptyResult))
+	err = Scan(newMockRows([]string{"id"}), &p)
+	Assert(t, err, Equal(ErrEmptyResult))
+}
+
+func TestScannerIgnoreColumns(t *testing.T) {
+	type Doc struct {
+		ID   int64  `pg:"id"`
+		Body []byte `pg:"body"`
+	}
+	s := &Scanner{IgnoreColumns: []string{"body"}}
+	newRows := func() *mockRows {
+		return newMockRows([]string{"id", "body"}, []interface{}{int64(1), []byte("huge")})
+	}
+	var docs []Doc
+	err := s.Scan(newRows(), &docs)
+	Assert(t, err, NilVal())
+	Assert(t, docs, Equal([]Doc{{ID: 1}}))
+
+	var row map[string]interface{}
+	err = s.Scan(newRows(), &row)
+	Assert(t, err, NilVal())
+	Assert(t, row, Equal(map[string]interface{}{"id": int64(1)}))
+
+	var d Doc
+	err = s.ScanOne(newRows(), &d)
+	Assert(t, err, NilVal())
+	Assert(t, d.Body == nil, Equal(true))
+
+	err = s.ScanEach(newRows(), &d, func() error {
+		Assert(t, d, Equal(Doc{ID: 1}))
+		return nil
+	})
+	Assert(t, err, NilVal())
+}
+
+func TestScannerInitEmptySlice(t *testing.T) {
+	type User struct {
+		ID int64 `pg:"id"`
+	}
+	var users []User
+	err := Scan(newMockRows([]string{"id"}), &users)
+	Assert(t, err, NilVal())
+	Assert(t, users == nil, Equal(true))
+
+	s := &Scanner{InitEmptySlice: true}
+	err = s.Scan(newMockRows([]string{"id"}), &users)
+	Assert(t, err, NilVal())
+	Assert(t, users == nil, Equal(false))
+	Assert(t, len(users), Equal(0))
+	data, _ := json.Marshal(users)
+	Assert(t, string(data), Equal("[]"))
+
+	var rows []map[string]interface{}
+	err = s.Scan(newMockRows([]string{"id"}), &rows)
+	Assert(t, err, NilVal())
+	Assert(t, rows == nil, Equal(false))
+}
+
+func TestDescribeRow(t *testing.T) {
+	now := time.Now()
+	rows := newMockRows([]string{"id", "name", "created_at", "deleted_at"},
+		[]interface{}{int64(1), []byte("a"), now, nil},
+		[]interface{}{int64(2), []byte("b"), now, nil},
+	)
+	types, err := DescribeRow(rows)
+	Assert(t, err, NilVal())
+	Assert(t, types, Equal(map[string]string{"id": "int64", "name": "[]uint8", "created_at": "time.Time", "deleted_at": "nil"}))
+	Assert(t, rows.closed, Equal(false))
+
+	//剩余的行仍可继续扫描
+	var list []map[string]interface{}
+	err = Scan(rows, &list)
+	Assert(t, err, NilVal())
+	Assert(t, len(list), Equal(1))
+	Assert(t, list[0]["id"], Equal(int64(2)))
+
+	_, err = DescribeRow(newMockRows([]string{"id"}))
+	Assert(t, err, Equal(ErrEmptyResult))
+}
+
+func TestScanN(t *testing.T) {
+	type Stu struct {
+		Age int64 `pg:"age"`
+	}
+	var students []Stu
+	n, err := ScanN(newMockRows([]string{"age"}, []interface{}{int64(1)}, []interface{}{int64(2)}), &students)
+	Assert(t, err, NilVal())
+	Assert(t, n, Equal(2))
+
+	var stu Stu
+	n, err = ScanN(newMockRows([]string{"age"}, []interface{}{int64(1)}, []interface{}{int64(2)}), &stu)
+	Assert(t, err, NilVal())
+	Assert(t, n, Equal(1))
+
+	n, err = ScanN(newMockRows([]string{"age"}), &stu)
+	Assert(t, err, Equal(ErrEmptyResult))
+	Assert(t, n, Equal(0))
+
+	n, err = ScanN(newMockRows([]string{"age"}), &students)
+	Assert(t, err, NilVal())
+	Assert(t, n, Equal(0))
+}
+
+func TestScanOne(t *testing.T) {
+	type Stu struct {
+		Age int64 `pg:"age"`
+	}
+	var stu Stu
+	err := ScanOne(newMockRows([]string{"age"}, []interface{}{int64(1)}), &stu)
+	Assert(t, err, NilVal())
+	Assert(t, stu.Age, Equal(int64(1)))
+
+	err = ScanOne(newMockRows([]string{"age"}), &stu)
+	Assert(t, err, Equal(ErrEmptyResult))
+
+	err = ScanOne(newMockRows([]string{"age"}, []interface{}{int64(1)}, []interface{}{int64(2)}), &stu)
+	Assert(t, err, Equal(ErrMultipleResults))
+}
+
+func TestExtraRows(t *testing.T) {
+	rows := newMockRows([]string{"b", "a", "c"}, []interface{}{int64(1), []byte("x"), nil}, []interface{}{int64(2), []byte("y"), true})
+	columns, values, err := ExtraRows(rows)
+	Assert(t, err, NilVal())
+	Assert(t, columns, Equal([]string{"b", "a", "c"}))
+	Assert(t, values, Equal([][]interface{}{{int64(1), []byte("x"), nil}, {int64(2), []byte("y"), true}}))
+}
+
+func TestScanContext(t *testing.T) {
+	type Stu struct {
+		Age int64 `pg:"age"`
+	}
+	data := make([][]interface{}, 200)
+	for i := range data {
+		data[i] = []interface{}{int64(i)}
+	}
+	var students []Stu
+	rows := newMockRows([]string{"age"}, data...)
+	err := ScanContext(context.Background(), rows, &students)
+	Assert(t, err, NilVal())
+	Assert(t, len(students), Equal(200))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	students = nil
+	rows = newMockRows([]string{"age"}, data...)
+	err = ScanContext(ctx, rows, &students)
+	Assert(t, err, Equal(context.Canceled))
+	Assert(t, rows.closed, Equal(true))
+	Assert(t, rows.closes, Equal(1))
+	Assert(t, students == nil, Equal(true))
+}
+
+func TestScanCloseRows(t *testing.T) {
+	type Stu struct {
+		Age int64 `pg:"age"`
+	}
+	closeErr := errors.New("close failed")
+	var stu Stu
+	rows := newMockRows([]string{"age"}, []interface{}{int64(1)})
+	rows.closeErr = closeErr
+	err := Scan(rows, &stu)
+	Assert(t, err, Equal(closeErr))
+	Assert(t, rows.closed, Equal(true))
+	Assert(t, stu.Age, Equal(int64(1)))
+
+	rows = newMockRows([]string{"age"})
+	rows.closeErr = closeErr
+	err = Scan(rows, &stu)
+	Assert(t, err, Equal(ErrEmptyResult))
+	Assert(t, rows.closed, Equal(true))
+
+	rows = newMockRows([]string{"age"}, []interface{}{int64(2)})
+	err = ScanNoClose(rows, &stu)
+	Assert(t, err, NilVal())
+	Assert(t, rows.closed, Equal(false))
+	Assert(t, stu.Age, Equal(int64(2)))
+}
+
+func TestMustScan(t *testing.T) {
+	type Stu struct {
+		Age int64 `pg:"age"`
+	}
+	var stu Stu
+	MustScan(newMockRows([]string{"age"}, []interface{}{int64(1)}), &stu)
+	Assert(t, stu.Age, Equal(int64(1)))
+
+	defer func() {
+		err, _ := recover().(error)
+		Assert(t, IsEmptyResult(err), Equal(true))
+	}()
+	MustScan(newMockRows([]string{"age"}), &stu)
+	t.Fatal("MustScan should panic on empty result")
+}
+
+//Scan时panic的数据集
+type panicRows struct {
+	*mockRows
+}
+
+func (p *panicRows) Scan(dest ...interface{}) error {
+	panic("malformed row")
+}
+
+func TestSafeScan(t *testing.T) {
+	type Stu struct {
+		Age int64 `pg:"age"`
+	}
+	rows := &panicRows{newMockRows([]string{"age"}, []interface{}{int64(1)})}
+	var stu Stu
+	err := SafeScan(rows, &stu)
+	Assert(t, errors.Is(err, ErrScanPanic), Equal(true))
+	Assert(t, err.Error(), Equal(ErrScanPanic.Error()+": malformed row"))
+	Assert(t, rows.closed, Equal(true))
+
+	err = (&Scanner{}).SafeScan(newMockRows([]string{"age"}, []interface{}{int64(2)}), &stu)
+	Assert(t, err, NilVal())
+	Assert(t, stu.Age, Equal(int64(2)))
+
+	err = SafeScan(newMockRows([]string{"age"}), &stu)
+	Assert(t, err, Equal(ErrEmptyResult))
+}
+
+func TestScanNilRows(t *testing.T) {
+	type Stu struct {
+		Age int64 `pg:"age"`
+	}
+	var typedNil *mockRows
+	for _, rows := range []IRows{nil, typedNil} {
+		var stu Stu
+		var list []Stu
+		Assert(t, Scan(rows, &stu), Equal(ErrNilRows))
+		Assert(t, ScanNoClose(rows, &list), Equal(ErrNilRows))
+		Assert(t, ScanOne(rows, &stu), Equal(ErrNilRows))
+		Assert(t, SafeScan(rows, &stu), Equal(ErrNilRows))
+		Assert(t, ScanEach(rows, &stu, func() error { return nil }), Equal(ErrNilRows))
+		_, err := ScanN(rows, &list)
+		Assert(t, err, Equal(ErrNilRows))
+		_, err = ExtraDatasFromRows(rows)
+		Assert(t, err, Equal(ErrNilRows))
+		_, _, err = ExtraRows(rows)
+		Assert(t, err, Equal(ErrNilRows))
+		_, err = DescribeRow(rows)
+		Assert(t, err, Equal(ErrNilRows))
+		_, err = ScanColumn[int64](rows, "age")
+		Assert(t, err, Equal(ErrNilRows))
+		_, err = ScanWithStats(rows, &list)
+		Assert(t, err, Equal(ErrNilRows))
+	}
+}
+
+//指定行rows.Scan出错的数据集
+type scanErrRows struct {
+	*mockRows
+	failRow int
+}
+
+var errCorruptRow = errors.New("corrupt row")
+
+func (r *scanErrRows) Scan(dest ...interface{}) error {
+	if r.cursor == r.failRow {
+		return errCorruptRow
+	}
+	return r.mockRows.Scan(dest...)
+}
+
+func TestScannerSkipScanErrors(t *testing.T) {
+	type Stu struct {
+		Age int64 `pg:"age"`
+	}
+	rows := func() *scanErrRows {
+		return &scanErrRows{newMockRows([]string{"age"}, []interface{}{int64(1)}, []interface{}{int64(2)}, []interface{}{int64(3)}), 1}
+	}
+	var list []Stu
+	err := Scan(rows(), &list)
+	Assert(t, err, Equal(errCorruptRow))
+
+	for _, s := range []*Scanner{{SkipScanErrors: true}, {SkipScanErrors: true, ReuseBuffers: true}} {
+		list = nil
+		n, err := s.ScanN(rows(), &list)
+		Assert(t, n, Equal(2))
+		Assert(t, list, Equal([]Stu{{1}, {3}}))
+		var skipped *SkippedRowsError
+		Assert(t, errors.As(err, &skipped), Equal(true))
+		Assert(t, len(skipped.Rows), Equal(1))
+		Assert(t, skipped.Rows[0].Row, Equal(1))
+		Assert(t, errors.Is(err, errCorruptRow), Equal(true))
+	}
+
+	stats, err := (&Scanner{SkipScanErrors: true}).ScanWithStats(rows(), &list)
+	Assert(t, errors.Is(err, errCorruptRow), Equal(true))
+	Assert(t, stats.Rows, Equal(2))
+	Assert(t, stats.SkippedRows, Equal(1))
+
+	//其余一次性提取数据的扫描同样保留未跳过的行
+	s := &Scanner{SkipScanErrors: true}
+	var one Stu
+	err = s.ScanOne(&scanErrRows{newMockRows([]string{"age"}, []interface{}{int64(1)}, []interface{}{int64(2)}), 1}, &one)
+	Assert(t, errors.Is(err, errCorruptRow), Equal(true))
+	Assert(t, one, Equal(Stu{1}))
+	err = s.ScanOne(rows(), &one)
+	Assert(t, err, Equal(ErrMultipleResults))
+
+	byAge, err := s.ScanMapBy(rows(), "age")
+	Assert(t, errors.Is(err, errCorruptRow), Equal(true))
+	Assert(t, len(byAge), Equal(2))
+	Assert(t, len(byAge[int64(3)]), Equal(1))
+
+	list = nil
+	err = s.ScanGrouped(rows(), &list, "age")
+	Assert(t, errors.Is(err, errCorruptRow), Equal(true))
+	Assert(t, list, Equal([]Stu{{1}, {3}}))
+}
+
+func TestIsEmptyResult(t *testing.T) {
+	Assert(t, IsEmptyResult(ErrEmptyResult), Equal(true))
+	Assert(t, IsEmptyResult(fmt.Errorf("query user: %w", ErrEmptyResult)), Equal(true))
+	Assert(t, IsEmptyResult(ErrConvertValue), Equal(false))
+	Assert(t, IsEmptyResult(nil), Equal(false))
+}
+
+func TestScanFields(t *testing.T) {
+	type Address struct {
+		City string `pg:"city"`
+	}
+	type User struct {
+		ID      int64   `pg:"id"`
+		Name    string  `pg:"name"`
+		Email   string  `pg:"email"`
+		Status  string  `pg:"status,default=active"`
+		Address Address `pg:"addr_,prefix"`
+	}
+	columns := []string{"id", "name", "email", "status", "addr_city"}
+	row := []interface{}{int64(1), []byte("tencent"), []byte("a@b.c"), nil, []byte("shenzhen")}
+	var list []User
+	err := ScanFields(newMockRows(columns, row), &list, []string{"id", "email", "addr_city"})
+	Assert(t, err, NilVal())
+	Assert(t, list, Equal([]User{{ID: 1, Email: "a@b.c", Address: Address{City: "shenzhen"}}}))
+
+	var u User
+	err = (&Scanner{CaseInsensitive: true}).ScanFields(newMockRows(columns, row), &u, []string{"name"})
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{Name: "tencent"}))
+
+	//按候选列名指定字段
+	type Order struct {
+		ID      int64  `pg:"id"`
+		Created string `pg:"created_at|creation_time"`
+	}
+	var o Order
+	err = ScanFields(newMockRows([]string{"id", "creation_time"}, []interface{}{int64(1), []byte("2020-01-01")}), &o, []string{"creation_time"})
+	Assert(t, err, NilVal())
+	Assert(t, o, Equal(Order{Created: "2020-01-01"}))
+
+	//不影响原扫描器
+	u = User{}
+	err = Scan(newMockRows(columns, row), &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.Status, Equal("active"))
+	Assert(t, u.Name, Equal("tencent"))
+}
+
+func TestScannerOnRow(t *testing.T) {
+	type User struct {
+		ID int64 `pg:"id"`
+	}
+	rows := func() *mockRows {
+		return newMockRows([]string{"id"}, []interface{}{int64(1)}, []interface{}{int64(2)}, []interface{}{int64(3)})
+	}
+	var indexes []int
+	var ids []interface{}
+	s := &Scanner{OnRow: func(index int, row map[string]interface{}) error {
+		indexes = append(indexes, index)
+		ids = append(ids, row["id"])
+		return nil
+	}}
+	var list []User
+	err := s.Scan(rows(), &list)
+	Assert(t, err, NilVal())
+	Assert(t, len(list), Equal(3))
+	Assert(t, indexes, Equal([]int{0, 1, 2}))
+	Assert(t, ids, Equal([]interface{}{int64(1), int64(2), int64(3)}))
+
+	errStop := errors.New("stop")
+	s.OnRow = func(index int, row map[string]interface{}) error {
+		if index == 1 {
+			return errStop
+		}
+		return nil
+	}
+	list = nil
+	err = s.Scan(rows(), &list)
+	Assert(t, err, Equal(errStop))
+	Assert(t, list == nil, Equal(true))
+
+	//map slice目标同样逐行调用
+	var maps []map[string]interface{}
+	err = s.Scan(rows(), &maps)
+	Assert(t, err, Equal(errStop))
+	Assert(t, maps == nil, Equal(true))
+
+	indexes = nil
+	s.OnRow = func(index int, row map[string]interface{}) error {
+		indexes = append(indexes, index)
+		return nil
+	}
+	err = s.Scan(rows(), &maps)
+	Assert(t, err, NilVal())
+	Assert(t, len(maps), Equal(3))
+	Assert(t, indexes, Equal([]int{0, 1, 2}))
+}
+
+func TestScannerTrimSpace(t *testing.T) {
+	type Code string
+	type Product struct {
+		Code  Code    `pg:"code"`
+		Name  string  `pg:"name"`
+		Ptr   *string `pg:"name"`
+		Count int64   `pg:"count"`
+	}
+	rows := func() *mockRows {
+		return newMockRows([]string{"code", "name", "count"}, []interface{}{[]byte("AB  "), "  pen   ", int64(3)})
+	}
+	var p Product
+	err := (&Scanner{TrimSpace: true}).Scan(rows(), &p)
+	Assert(t, err, NilVal())
+	Assert(t, p.Code, Equal(Code("AB")))
+	Assert(t, p.Name, Equal("  pen"))
+	Assert(t, *p.Ptr, Equal("  pen"))
+	Assert(t, p.Count, Equal(int64(3)))
+
+	err = Scan(rows(), &p)
+	Assert(t, err, NilVal())
+	Assert(t, p.Code, Equal(Code("AB  ")))
+	Assert(t, p.Name, Equal("  pen   "))
+
+	//定长列中的NULL字面量带填充空格，去掉空格后*string字段应为nil
+	var n Product
+	err = (&Scanner{TrimSpace: true, NullLiterals: []string{"NULL"}}).Scan(newMockRows([]string{"code", "name"}, []interface{}{"AB", []byte("NULL  ")}), &n)
+	Assert(t, err, NilVal())
+	Assert(t, n.Ptr == nil, Equal(true))
+	Assert(t, n.Name, Equal(""))
+}
+
+func TestScannerSingleStrict(t *testing.T) {
+	type User struct {
+		ID int64 `pg:"id"`
+	}
+	rows := func(n int) *mockRows {
+		data := make([][]interface{}, n)
+		for i := range data {
+			data[i] = []interface{}{int64(i + 1)}
+		}
+		return newMockRows([]string{"id"}, data...)
+	}
+	s := &Scanner{SingleStrict: true}
+	var u User
+	err := s.Scan(rows(2), &u)
+	Assert(t, err, Equal(ErrMultipleResults))
+	Assert(t, u, Equal(User{}))
+
+	var count int64
+	err = s.Scan(rows(2), &count)
+	Assert(t, err, Equal(ErrMultipleResults))
+
+	err = s.Scan(rows(1), &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.ID, Equal(int64(1)))
+
+	var list []User
+	err = s.Scan(rows(2), &list)
+	Assert(t, err, NilVal())
+	Assert(t, len(list), Equal(2))
+
+	//默认取第一行
+	err = Scan(rows(2), &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.ID, Equal(int64(1)))
+}
+
+func TestScannerWith(t *testing.T) {
+	type User struct {
+		UserID    int64     `db:"id" pg:"uid"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+	base := &Scanner{TagNames: []string{"pg"}, IgnoreColumns: []string{"secret"}, ReuseBuffers: true}
+	s := base.WithTag("db").WithTimeFormat(time.RFC3339).WithAutoMap()
+	Assert(t, s.TagName, Equal("db"))
+	Assert(t, s.TagNames == nil, Equal(true))
+	Assert(t, s.TimeFormats, Equal([]string{time.RFC3339}))
+	Assert(t, s.AutoMap, Equal(true))
+
+	//基础扫描器不受影响
+	Assert(t, base.TagName, Equal(""))
+	Assert(t, base.TagNames, Equal([]string{"pg"}))
+	Assert(t, base.TimeFormats == nil, Equal(true))
+	Assert(t, base.AutoMap, Equal(false))
+
+	rows := func() *mockRows {
+		return newMockRows([]string{"id", "uid", "created_at"}, []interface{}{int64(1), int64(2), []byte("2020-05-06T07:08:09Z")})
+	}
+	var u User
+	err := s.Scan(rows(), &u)
+	Assert(t, err, NilVal())
+	Assert(t, u.UserID, Equal(int64(1)))
+	Assert(t, u.CreatedAt, Equal(time.Date(2020, 5, 6, 7, 8, 9, 0, time.UTC)))
+
+	u = User{}
+	err = base.Scan(rows(), &u)
+	Assert(t, err, NilVal())
+	Assert(t, u, Equal(User{UserID: 2}))
+
+	//副本不共享缓冲区及slice配置
+	clone := base.Clone()
+	Assert(t, clone.buffers == nil, Equal(true))
+	Assert(t, base.buffers == nil, Equal(false))
+	clone.IgnoreColumns[0] = "other"
+	Assert(t, base.IgnoreColumns, Equal([]string{"secret"}))
+}
+
+func TestScannerHexDecodeBytes(t *testing.T) {
+	type File struct {
+		Data []byte          `pg:"data"`
+		Raw  json.RawMessage `pg:"raw"`
+		Ptr  *[]byte         `pg:"data"`
+		Text string          `pg:"data"`
+	}
+	mp := map[string]interface{}{"data": []byte(`\x48656c6c6f`), "raw": `{"a":1}`}
+	var f File
+	s := &Scanner{HexDecodeBytes: true}
+	err := s.singleResult(mp, &f)
+	Assert(t, err, NilVal())
+	Assert(t, f.Data, Equal([]byte("Hello")))
+	Assert(t, *f.Ptr, Equal([]byte("Hello")))
+	Assert(t, string(f.Raw), Equal(`{"a":1}`))
+	Assert(t, f.Text, Equal(`\x48656c6c6f`))
+
+	err = s.singleResult(map[string]interface{}{"data": `\x00ff`}, &f)
+	Assert(t, err, NilVal())
+	Assert(t, f.Data, Equal([]byte{0x00, 0xff}))
+
+	err = s.singleResult(map[string]interface{}{"data": []byte(`\xzz`)}, &f)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	//默认不解码
+	err = defaultScanner.singleResult(mp, &f)
+	Assert(t, err, NilVal())
+	Assert(t, f.Data, Equal([]byte(`\x48656c6c6f`)))
+}
+
+func TestScannerColumnConverters(t *testing.T) {
+	type Product struct {
+		ID    int64  `pg:"id"`
+		Price int64  `pg:"price"`
+		Tags  string `pg:"tags"`
+	}
+	//价格以元为单位的文本，转换为分
+	s := &Scanner{ColumnConverters: map[string]ConverterFunc{
+		"price": func(src interface{}) (interface{}, error) {
+			f, err := strconv.ParseFloat(string(src.([]byte)), 64)
+			if err != nil {
+				return nil, err
+			}
+			return int64(f*100 + 0.5), nil
+		},
+	}}
+	rows := func() *mockRows {
+		return newMockRows([]string{"id", "price", "tags"}, []interface{}{int64(1), []byte("12.34"), []byte("a,b")})
+	}
+	var list []Product
+	err := s.Scan(rows(), &list)
+	Assert(t, err, NilVal())
+	Assert(t, list, Equal([]Product{{ID: 1, Price: 1234, Tags: "a,b"}}))
+
+	err = Scan(rows(), &list)
+	Assert(t, errors.Is(err, ErrConvertValue), Equal(true))
+
+	clone := s.Clone()
+	delete(clone.ColumnConverters, "price")
+	Assert(t, len(s.ColumnConverters), Equal(1))
+
+	//指针字段按返回值分配新值，NULL时保持nil且不调用转换函数
+	type Stock struct {
+		Count *int `pg:"count"`
+	}
+	calls := 0
+	ps := &Scanner{ColumnConverters: map[string]ConverterFunc{
+		"count": func(src interface{}) (interface{}, error) {
+			calls++
+			return 5, nil
+		},
+	}}
+	var stocks []Stock
+	err = ps.Scan(newMockRows([]string{"count"}, []interface{}{[]byte("five")}, []interface{}{nil}), &stocks)
+	Assert(t, err, NilVal())
+	Assert(t, len(stocks), Equal(2))
+	Assert(t, *stocks[0].Count, Equal(5))
+	Assert(t, stocks[1].Count == nil, Equal(true))
+	Assert(t, calls, Equal(1))
+
+	//按实际匹配的列名查找转换函数，包括忽略大小写及候选列名匹配到的列
+	type Order struct {
+		Total int64 `pg:"total|amount"`
+		Fee   int64 `pg:"fee"`
+	}
+	cents := func(src interface{}) (interface{}, error) {
+		f, err := strconv.ParseFloat(string(src.([]byte)), 64)
+		return int64(f*100 + 0.5), err
+	}
+	cs := &Scanner{CaseInsensitive: true, ColumnConverters: map[string]ConverterFunc{"amount": cents, "FEE": cents}}
+	var o Order
+	err = cs.Scan(newMockRows([]string{"amount", "FEE"}, []interface{}{[]byte("1.5"), []byte("0.25")}), &o)
+	Assert(t, err, NilVal())
+	Assert(t, o, Equal(Order{Total: 150, Fee: 25}))
+}
@@ -0,0 +1,49 @@
+package db_scan
+
+//扫描统计信息，用于测试及调优时确认映射结果是否符合预期
+type Stats struct {
+	Rows             int //写入目标对象的行数
+	Columns          int //结果集的列数
+	FieldsMatched    int //匹配到列的结构体字段数，按首行统计，子结构体展开后计数
+	ColumnsUnmatched int //没有对应结构体字段的列数，按首行统计，由extra字段接收的列也计入
+	Conversions      int //结构体字段执行值转换的次数，所有行合计
+	SkippedRows      int //SkipScanErrors模式下因rows.Scan出错而跳过的行数
+}
+
+//数据集扫描，同时返回扫描的统计信息
+func ScanWithStats(rows IRows, target interface{}) (Stats, error) {
+	return defaultScanner.ScanWithStats(rows, target)
+}
+
+//使用扫描器的配置进行数据集扫描，同时返回扫描的统计信息
+func (s *Scanner) ScanWithStats(rows IRows, target interface{}) (Stats, error) {
+	var stats Stats
+	if columns, err := rowColumns(rows); nil == err {
+		stats.Columns = len(columns)
+	}
+	scanner := *s
+	scanner.stats = &stats
+	n, err := scanner.ScanN(rows, target)
+	stats.Rows = n
+	return stats, err
+}
+
+//按首行统计结构体字段与列的匹配情况，非结构体目标及按单列值接收的结构体（如big.Int）不统计
+func (s *Scanner) collectStats(result map[string]interface{}, target interface{}) {
+	if s.stats == nil {
+		return
+	}
+	typeObj, ok := targetStructType(getPtrObjectType(target))
+	if !ok || isScalarType(typeObj) {
+		return
+	}
+	fields := s.structFields(typeObj)
+	matched, _ := s.matchedColumns(result, fields)
+	columns := s.columnsOf(result)
+	walkFields(fields, func(field fieldInfo) {
+		if _, ok := columns.match(field); ok {
+			s.stats.FieldsMatched++
+		}
+	})
+	s.stats.ColumnsUnmatched = len(result) - len(matched)
+}
@@ -0,0 +1,45 @@
+package db_scan
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+func TestScanWithStats(t *testing.T) {
+	type Address struct {
+		City string `pg:"city"`
+	}
+	type User struct {
+		ID      int64    `pg:"id"`
+		Name    string   `pg:"name"`
+		Email   string   `pg:"email"`
+		Address *Address `pg:"addr_,prefix"`
+	}
+	rows := newMockRows([]string{"id", "name", "addr_city", "age", "note"},
+		[]interface{}{int64(1), []byte("a"), []byte("sz"), int64(18), nil},
+		[]interface{}{int64(2), []byte("b"), nil, int64(20), nil},
+	)
+	var list []User
+	stats, err := ScanWithStats(rows, &list)
+	Assert(t, err, NilVal())
+	Assert(t, len(list), Equal(2))
+	Assert(t, stats, Equal(Stats{Rows: 2, Columns: 5, FieldsMatched: 3, ColumnsUnmatched: 2, Conversions: 6}))
+
+	var u User
+	stats, err = (&Scanner{IgnoreColumns: []string{"name"}}).ScanWithStats(
+		newMockRows([]string{"id", "name"}, []interface{}{int64(1), []byte("a")}), &u)
+	Assert(t, err, NilVal())
+	Assert(t, stats, Equal(Stats{Rows: 1, Columns: 2, FieldsMatched: 1, ColumnsUnmatched: 0, Conversions: 1}))
+
+	var ids []int64
+	stats, err = ScanWithStats(newMockRows([]string{"id"}, []interface{}{int64(1)}), &ids)
+	Assert(t, err, NilVal())
+	Assert(t, stats, Equal(Stats{Rows: 1, Columns: 1}))
+
+	var totals []big.Int
+	stats, err = ScanWithStats(newMockRows([]string{"total"}, []interface{}{[]byte("1")}), &totals)
+	Assert(t, err, NilVal())
+	Assert(t, stats, Equal(Stats{Rows: 1, Columns: 1}))
+}
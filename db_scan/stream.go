@@ -0,0 +1,151 @@
+package db_scan
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+var ErrNotChannel = errors.New("目标不是可发送的channel")
+
+//逐行扫描数据集，每行写入elemPtr后调用fn，fn返回错误时终止扫描
+//elemPtr在各行之间复用，每行扫描前重置为零值，fn中需要保留数据时必须自行拷贝
+func ScanEach(rows IRows, elemPtr interface{}, fn func() error) error {
+	return defaultScanner.ScanEach(rows, elemPtr, fn)
+}
+
+//使用扫描器的配置逐行扫描数据集
+func (s *Scanner) ScanEach(rows IRows, elemPtr interface{}, fn func() error) error {
+	return s.scanEach(rows, elemPtr, fn, false)
+}
+
+//零拷贝逐行扫描，列值以sql.RawBytes接收，[]byte字段直接引用驱动的缓冲区
+//elemPtr中的[]byte字段只在fn执行期间有效，下一次rows.Next()之后即被覆盖，需要保留时必须自行拷贝
+//非文本列由database/sql格式化为文本后再转换，时间列按time.RFC3339Nano解析
+func ScanEachRaw(rows IRows, elemPtr interface{}, fn func() error) error {
+	return defaultScanner.ScanEachRaw(rows, elemPtr, fn)
+}
+
+//使用扫描器的配置进行零拷贝逐行扫描
+func (s *Scanner) ScanEachRaw(rows IRows, elemPtr interface{}, fn func() error) error {
+	return s.scanEach(rows, elemPtr, fn, true)
+}
+
+//逐行扫描数据集，每行先调用newElem获取接收该行的对象（必须为非空指针），扫描后调用appendFn交给调用方保存
+//相比Scan由调用方控制对象的分配与存储，如写入channel、有界缓冲区或自定义集合；appendFn返回错误时终止扫描
+func ScanAppend(rows IRows, appendFn func(elem interface{}) error, newElem func() interface{}) error {
+	return defaultScanner.ScanAppend(rows, appendFn, newElem)
+}
+
+//使用扫描器的配置逐行扫描数据集并交由appendFn保存
+func (s *Scanner) ScanAppend(rows IRows, appendFn func(elem interface{}) error, newElem func() interface{}) (err error) {
+	defer closeRows(rows, &err)
+	return s.eachRow(rows, false, func() (interface{}, error) {
+		elem := newElem()
+		if !isSettableTarget(elem) {
+			return nil, ErrTargetNotSettable
+		}
+		return elem, nil
+	}, appendFn)
+}
+
+//逐行扫描数据集并发送到ch，ch为元素类型（如chan User或chan *User）的可发送channel，扫描结束后关闭ch
+//ctx取消时停止扫描并返回ctx.Err()，阻塞在发送上时同样可被取消；出错时ch也会被关闭
+func ScanToChannel(ctx context.Context, rows IRows, ch interface{}) error {
+	return defaultScanner.ScanToChannel(ctx, rows, ch)
+}
+
+//使用扫描器的配置逐行扫描数据集并发送到ch
+func (s *Scanner) ScanToChannel(ctx context.Context, rows IRows, ch interface{}) (err error) {
+	defer closeRows(rows, &err)
+	chObj := reflect.ValueOf(ch)
+	if chObj.Kind() != reflect.Chan || chObj.Type().ChanDir()&reflect.SendDir == 0 || chObj.IsNil() {
+		return ErrNotChannel
+	}
+	defer chObj.Close()
+	elemType := chObj.Type().Elem()
+	ptr := elemType.Kind() == reflect.Ptr
+	if ptr {
+		elemType = elemType.Elem()
+	}
+	done := reflect.ValueOf(ctx.Done())
+	return s.eachRow(rows, false, func() (interface{}, error) {
+		if err := ctx.Err(); nil != err {
+			return nil, err
+		}
+		return reflect.New(elemType).Interface(), nil
+	}, func(elem interface{}) error {
+		value := reflect.ValueOf(elem)
+		if !ptr {
+			value = value.Elem()
+		}
+		chosen, _, _ := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectSend, Chan: chObj, Send: value},
+			{Dir: reflect.SelectRecv, Chan: done},
+		})
+		if chosen == 1 {
+			return ctx.Err()
+		}
+		return nil
+	})
+}
+
+func (s *Scanner) scanEach(rows IRows, elemPtr interface{}, fn func() error, raw bool) (err error) {
+	defer closeRows(rows, &err)
+	if !isSettableTarget(elemPtr) {
+		return ErrTargetNotSettable
+	}
+	elem := getPtrObjectValue(elemPtr)
+	zero := reflect.Zero(elem.Type())
+	return s.eachRow(rows, raw, func() (interface{}, error) {
+		elem.Set(zero)
+		return elemPtr, nil
+	}, func(interface{}) error {
+		return fn()
+	})
+}
+
+//逐行读取数据，target返回接收当前行的对象，扫描后调用fn；转换失败的字段汇总为*MultiError返回
+func (s *Scanner) eachRow(rows IRows, raw bool, target func() (interface{}, error), fn func(elem interface{}) error) (err error) {
+	reader, err := s.newRowReader(rows, raw)
+	if nil != err {
+		return err
+	}
+	scanner := s.withColumns(reader.columns)
+	var rowErrors []*RowError
+	var row map[string]interface{}
+	for count := 0; rows.Next(); count++ {
+		//零拷贝模式下行数据只在当前行有效，行map也一并复用
+		if !raw {
+			row = nil
+		}
+		row, err = reader.readInto(row)
+		if nil != err {
+			return err
+		}
+		s.dropIgnoredColumns(row)
+		var elemPtr interface{}
+		if elemPtr, err = target(); nil != err {
+			return err
+		}
+		if count == 0 {
+			if err = scanner.checkColumns(row, elemPtr); nil != err {
+				return err
+			}
+		}
+		if err = scanner.rowResult(row, elemPtr); nil != err {
+			errs, ok := err.(fieldErrors)
+			if !ok {
+				return err
+			}
+			rowErrors = append(rowErrors, &RowError{Row: count, Errors: errs})
+		}
+		if err = fn(elemPtr); nil != err {
+			return err
+		}
+	}
+	if len(rowErrors) > 0 {
+		return &MultiError{Rows: rowErrors}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package db_scan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+func TestScanEach(t *testing.T) {
+	type Stu struct {
+		Age  int64  `pg:"age"`
+		Name string `pg:"name"`
+	}
+	rows := newMockRows([]string{"age", "name"},
+		[]interface{}{int64(1), []byte("a")},
+		[]interface{}{int64(2), nil},
+		[]interface{}{int64(3), []byte("c")},
+	)
+	var stu Stu
+	var collected []Stu
+	err := ScanEach(rows, &stu, func() error {
+		collected = append(collected, stu)
+		return nil
+	})
+	Assert(t, err, NilVal())
+	Assert(t, rows.closed, Equal(true))
+	Assert(t, collected, Equal([]Stu{{1, "a"}, {2, ""}, {3, "c"}}))
+
+	stop := errors.New("stop")
+	count := 0
+	rows = newMockRows([]string{"age", "name"}, []interface{}{int64(1), []byte("a")}, []interface{}{int64(2), []byte("b")})
+	err = ScanEach(rows, &stu, func() error {
+		count++
+		return stop
+	})
+	Assert(t, err, Equal(stop))
+	Assert(t, count, Equal(1))
+}
+
+func TestScanAppend(t *testing.T) {
+	type Stu struct {
+		Age  int64  `pg:"age"`
+		Name string `pg:"name"`
+	}
+	rows := newMockRows([]string{"age", "name"},
+		[]interface{}{int64(1), []byte("a")},
+		[]interface{}{int64(2), []byte("b")},
+	)
+	ch := make(chan *Stu, 2)
+	err := ScanAppend(rows, func(elem interface{}) error {
+		ch <- elem.(*Stu)
+		return nil
+	}, func() interface{} {
+		return new(Stu)
+	})
+	close(ch)
+	Assert(t, err, NilVal())
+	Assert(t, rows.closed, Equal(true))
+	var collected []Stu
+	for stu := range ch {
+		collected = append(collected, *stu)
+	}
+	Assert(t, collected, Equal([]Stu{{1, "a"}, {2, "b"}}))
+
+	stop := errors.New("stop")
+	count := 0
+	rows = newMockRows([]string{"age"}, []interface{}{int64(1)}, []interface{}{int64(2)})
+	err = ScanAppend(rows, func(elem interface{}) error {
+		count++
+		return stop
+	}, func() interface{} {
+		return new(Stu)
+	})
+	Assert(t, err, Equal(stop))
+	Assert(t, count, Equal(1))
+
+	rows = newMockRows([]string{"age"}, []interface{}{int64(1)})
+	err = ScanAppend(rows, func(elem interface{}) error {
+		return nil
+	}, func() interface{} {
+		return Stu{}
+	})
+	Assert(t, err, Equal(ErrTargetNotSettable))
+	Assert(t, rows.closed, Equal(true))
+}
+
+func TestScanToChannel(t *testing.T) {
+	type Stu struct {
+		Age  int64  `pg:"age"`
+		Name string `pg:"name"`
+	}
+	newRows := func() *mockRows {
+		return newMockRows([]string{"age", "name"},
+			[]interface{}{int64(1), []byte("a")},
+			[]interface{}{int64(2), []byte("b")},
+			[]interface{}{int64(3), []byte("c")},
+		)
+	}
+	rows := newRows()
+	ch := make(chan Stu)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ScanToChannel(context.Background(), rows, ch)
+	}()
+	var collected []Stu
+	for stu := range ch {
+		collected = append(collected, stu)
+	}
+	Assert(t, <-errCh, NilVal())
+	Assert(t, rows.closed, Equal(true))
+	Assert(t, collected, Equal([]Stu{{1, "a"}, {2, "b"}, {3, "c"}}))
+
+	ptrCh := make(chan *Stu, 3)
+	err := ScanToChannel(context.Background(), newRows(), (chan<- *Stu)(ptrCh))
+	Assert(t, err, NilVal())
+	var ptrs []*Stu
+	for stu := range ptrCh {
+		ptrs = append(ptrs, stu)
+	}
+	Assert(t, len(ptrs), Equal(3))
+	Assert(t, *ptrs[2], Equal(Stu{3, "c"}))
+
+	//没有消费者时可通过ctx取消
+	ctx, cancel := context.WithCancel(context.Background())
+	blocked := make(chan Stu)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	rows = newRows()
+	err = ScanToChannel(ctx, rows, blocked)
+	Assert(t, err, Equal(context.Canceled))
+	Assert(t, rows.closed, Equal(true))
+	_, ok := <-blocked
+	Assert(t, ok, Equal(false))
+
+	err = ScanToChannel(context.Background(), newRows(), []Stu{})
+	Assert(t, err, Equal(ErrNotChannel))
+	err = ScanToChannel(context.Background(), newRows(), (<-chan Stu)(make(chan Stu)))
+	Assert(t, err, Equal(ErrNotChannel))
+}
+
+func TestScanEachRaw(t *testing.T) {
+	type Blob struct {
+		ID        int64     `pg:"id"`
+		Data      []byte    `pg:"data"`
+		Name      string    `pg:"name"`
+		Note      *string   `pg:"note"`
+		CreatedAt time.Time `pg:"created_at"`
+	}
+	payload := []byte("payload")
+	now := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	rows := newMockRows([]string{"id", "data", "name", "note", "created_at"},
+		[]interface{}{int64(1), payload, []byte("a"), nil, now},
+		[]interface{}{int64(2), []byte("other"), "b", "x", now},
+	)
+	var b Blob
+	var ids []int64
+	var shared bool
+	err := ScanEachRaw(rows, &b, func() error {
+		if b.ID == 1 {
+			shared = &b.Data[0] == &payload[0]
+			Assert(t, b.Note == nil, Equal(true))
+		} else {
+			Assert(t, *b.Note, Equal("x"))
+		}
+		Assert(t, b.CreatedAt.Equal(now), Equal(true))
+		ids = append(ids, b.ID)
+		return nil
+	})
+	Assert(t, err, NilVal())
+	Assert(t, ids, Equal([]int64{1, 2}))
+	Assert(t, shared, Equal(true))
+	Assert(t, b.Name, Equal("b"))
+	Assert(t, rows.closed, Equal(true))
+}
+
+func BenchmarkScanEachRaw(b *testing.B) {
+	type Blob struct {
+		ID   int64  `pg:"id"`
+		Data []byte `pg:"data"`
+	}
+	columns := []string{"id", "data"}
+	data := make([][]interface{}, 100)
+	for i := range data {
+		data[i] = []interface{}{int64(i), bytes.Repeat([]byte("x"), 256)}
+	}
+	run := func(b *testing.B, scan func(IRows, interface{}, func() error) error) {
+		b.ReportAllocs()
+		var blob Blob
+		for i := 0; i < b.N; i++ {
+			if err := scan(newMockRows(columns, data...), &blob, func() error { return nil }); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.Run("copy", func(b *testing.B) {
+		run(b, ScanEach)
+	})
+	b.Run("raw", func(b *testing.B) {
+		run(b, ScanEachRaw)
+	})
+}
@@ -0,0 +1,74 @@
+package db_scan
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrMissingColumn  = errors.New("结构体字段没有对应的列")
+	ErrUnmappedColumn = errors.New("列没有对应的结构体字段")
+)
+
+//StrictColumns模式下按首行校验列与目标结构体字段是否一一对应，非结构体目标及按单列值接收的结构体（如big.Int）不校验
+func (s *Scanner) checkColumns(result map[string]interface{}, target interface{}) error {
+	if !s.StrictColumns {
+		return nil
+	}
+	typeObj, ok := targetStructType(getPtrObjectType(target))
+	if !ok || isScalarType(typeObj) {
+		return nil
+	}
+	fields := s.structFields(typeObj)
+	matched, missing := s.matchedColumns(result, fields)
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingColumn, strings.Join(missing, ", "))
+	}
+	//未匹配的列由extra字段接收
+	if hasExtraField(fields) {
+		return nil
+	}
+	var unmapped []string
+	for column := range result {
+		if !matched[column] {
+			unmapped = append(unmapped, column)
+		}
+	}
+	if len(unmapped) > 0 {
+		sort.Strings(unmapped)
+		return fmt.Errorf("%w: %s", ErrUnmappedColumn, strings.Join(unmapped, ", "))
+	}
+	return nil
+}
+
+//匹配行数据的列与结构体字段，返回匹配到字段的列及没有对应列的字段列名
+func (s *Scanner) matchedColumns(result map[string]interface{}, fields []fieldInfo) (map[string]bool, []string) {
+	columns := s.columnsOf(result)
+	matched := make(map[string]bool, len(result))
+	var missing []string
+	walkFields(fields, func(field fieldInfo) {
+		column, ok := columns.match(field)
+		if !ok {
+			missing = append(missing, field.column)
+			return
+		}
+		matched[column] = true
+	})
+	return matched, missing
+}
+
+//遍历所有对应列的字段，子结构体展开为其字段，extra字段除外
+func walkFields(fields []fieldInfo, fn func(field fieldInfo)) {
+	for _, field := range fields {
+		if field.extra {
+			continue
+		}
+		if field.nested != nil {
+			walkFields(field.nested, fn)
+			continue
+		}
+		fn(field)
+	}
+}
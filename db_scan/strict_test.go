@@ -0,0 +1,67 @@
+package db_scan
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+func TestStrictColumns(t *testing.T) {
+	type User struct {
+		ID   int64  `pg:"id"`
+		Name string `pg:"name"`
+	}
+	strict := &Scanner{StrictColumns: true}
+
+	var users []User
+	err := strict.Scan(newMockRows([]string{"id", "name"}, []interface{}{int64(1), []byte("a")}), &users)
+	Assert(t, err, NilVal())
+	Assert(t, users, Equal([]User{{ID: 1, Name: "a"}}))
+
+	var u User
+	err = strict.Scan(newMockRows([]string{"id", "nmae"}, []interface{}{int64(1), []byte("a")}), &u)
+	Assert(t, errors.Is(err, ErrMissingColumn), Equal(true))
+	Assert(t, strings.Contains(err.Error(), "name"), Equal(true))
+
+	err = strict.Scan(newMockRows([]string{"id", "name", "email", "age"}, []interface{}{int64(1), []byte("a"), nil, nil}), &users)
+	Assert(t, errors.Is(err, ErrUnmappedColumn), Equal(true))
+	Assert(t, strings.HasSuffix(err.Error(), "age, email"), Equal(true))
+
+	err = strict.ScanEach(newMockRows([]string{"id"}, []interface{}{int64(1)}), &u, func() error { return nil })
+	Assert(t, errors.Is(err, ErrMissingColumn), Equal(true))
+
+	//默认不校验
+	err = Scan(newMockRows([]string{"id", "email"}, []interface{}{int64(1), nil}), &u)
+	Assert(t, err, NilVal())
+
+	//按单列值接收的结构体不校验
+	var totals []*big.Int
+	err = strict.Scan(newMockRows([]string{"total"}, []interface{}{[]byte("12345678901234567890")}), &totals)
+	Assert(t, err, NilVal())
+	Assert(t, totals[0].String(), Equal("12345678901234567890"))
+	var column columnValue
+	err = strict.Scan(newMockRows([]string{"v"}, []interface{}{[]byte("x")}), &column)
+	Assert(t, err, NilVal())
+	Assert(t, column.Value, Equal("x"))
+}
+
+func TestStrictColumnsNested(t *testing.T) {
+	type Audit struct {
+		CreatedBy string `pg:"by"`
+	}
+	type Post struct {
+		Title string `pg:"title"`
+		Audit *Audit `pg:"created_,prefix"`
+	}
+	strict := &Scanner{StrictColumns: true, CaseInsensitive: true}
+	var p Post
+	err := strict.Scan(newMockRows([]string{"TITLE", "created_by"}, []interface{}{[]byte("t"), []byte("me")}), &p)
+	Assert(t, err, NilVal())
+	Assert(t, p.Audit.CreatedBy, Equal("me"))
+
+	err = strict.Scan(newMockRows([]string{"title"}, []interface{}{[]byte("t")}), &p)
+	Assert(t, errors.Is(err, ErrMissingColumn), Equal(true))
+}
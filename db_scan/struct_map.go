@@ -0,0 +1,74 @@
+package db_scan
+
+import (
+	"errors"
+	"reflect"
+)
+
+var ErrNotStruct = errors.New("对象不是结构体或结构体指针")
+
+//结构体转换为以标签名为键的map，用于构造insert/update参数
+//标签为"-"及未设置标签的字段被忽略，指针字段取其指向的值，空指针对应nil
+func StructToMap(obj interface{}) (map[string]interface{}, error) {
+	return defaultScanner.StructToMap(obj)
+}
+
+//使用扫描器的配置将结构体转换为map，与扫描时的字段映射规则保持一致
+func (s *Scanner) StructToMap(obj interface{}) (map[string]interface{}, error) {
+	valueObj := reflect.ValueOf(obj)
+	for valueObj.Kind() == reflect.Ptr {
+		if valueObj.IsNil() {
+			return nil, ErrNotStruct
+		}
+		valueObj = valueObj.Elem()
+	}
+	if valueObj.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+	result := make(map[string]interface{})
+	collectFields(valueObj, s.structFields(valueObj.Type()), result)
+	return result, nil
+}
+
+//按字段映射信息收集结构体字段值，嵌入的空结构体指针其字段均对应nil
+func collectFields(valueObj reflect.Value, fields []fieldInfo, result map[string]interface{}) {
+	for _, field := range fields {
+		valueI := valueObj.Field(field.index)
+		if field.extra {
+			continue
+		}
+		if field.nested == nil {
+			result[field.column] = indirectValue(valueI)
+		} else if !field.ptr {
+			collectFields(valueI, field.nested, result)
+		} else if valueI.IsNil() {
+			collectNilFields(field.nested, result)
+		} else {
+			collectFields(valueI.Elem(), field.nested, result)
+		}
+	}
+}
+
+func collectNilFields(fields []fieldInfo, result map[string]interface{}) {
+	for _, field := range fields {
+		if field.extra {
+			continue
+		}
+		if field.nested == nil {
+			result[field.column] = nil
+		} else {
+			collectNilFields(field.nested, result)
+		}
+	}
+}
+
+//解引用指针，空指针返回nil
+func indirectValue(valueI reflect.Value) interface{} {
+	for valueI.Kind() == reflect.Ptr {
+		if valueI.IsNil() {
+			return nil
+		}
+		valueI = valueI.Elem()
+	}
+	return valueI.Interface()
+}
@@ -0,0 +1,42 @@
+package db_scan
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+func TestStructToMap(t *testing.T) {
+	type User struct {
+		BaseModel
+		*SoftDelete
+		Name    string  `pg:"name"`
+		Nick    *string `pg:"nick"`
+		Email   *string `pg:"email"`
+		Secret  string  `pg:"-"`
+		Display string
+	}
+	email := "a@b.c"
+	u := User{BaseModel: BaseModel{ID: 1}, Name: "tencent", Email: &email, Secret: "x", Display: "y"}
+	mp, err := StructToMap(&u)
+	Assert(t, err, NilVal())
+	Assert(t, mp, Equal(map[string]interface{}{
+		"id":         int64(1),
+		"deleted_at": nil,
+		"name":       "tencent",
+		"nick":       nil,
+		"email":      "a@b.c",
+	}))
+
+	deleted := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	u.SoftDelete = &SoftDelete{DeletedAt: deleted}
+	mp, err = StructToMap(u)
+	Assert(t, err, NilVal())
+	Assert(t, mp["deleted_at"], Equal(deleted))
+
+	_, err = StructToMap(1)
+	Assert(t, err, Equal(ErrNotStruct))
+	_, err = StructToMap((*User)(nil))
+	Assert(t, err, Equal(ErrNotStruct))
+}
@@ -0,0 +1,48 @@
+package db_scan
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrUnsupportedTarget = errors.New("不支持的扫描目标类型")
+	ErrNoMappedFields    = errors.New("结构体没有可映射的字段")
+)
+
+//不执行查询，检查目标对象能否用于扫描，适用于启动时检查DTO定义
+//目标须为非空指针，指向结构体、slice、键为string的map或基础类型；结构体须至少有一个字段能映射到列
+func ValidateTarget(target interface{}) error {
+	return defaultScanner.ValidateTarget(target)
+}
+
+//使用扫描器的配置（标签名称、AutoMap等）检查目标对象
+func (s *Scanner) ValidateTarget(target interface{}) error {
+	if !isSettableTarget(target) {
+		return ErrTargetNotSettable
+	}
+	typeObj := getPtrObjectType(target)
+	if typeObj.Kind() == reflect.Slice {
+		typeObj = typeObj.Elem()
+	}
+	for typeObj.Kind() == reflect.Ptr {
+		typeObj = typeObj.Elem()
+	}
+	if isScalarType(typeObj) {
+		return nil
+	}
+	switch typeObj.Kind() {
+	case reflect.Map:
+		if typeObj.Key().Kind() != reflect.String {
+			return fmt.Errorf("%w: map key %s", ErrUnsupportedTarget, typeObj.Key())
+		}
+		return nil
+	case reflect.Struct:
+		if len(s.structFields(typeObj)) == 0 {
+			return fmt.Errorf("%w: %s", ErrNoMappedFields, typeObj)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedTarget, typeObj)
+}
@@ -0,0 +1,42 @@
+package db_scan
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/tevid/gohamcrest"
+)
+
+func TestValidateTarget(t *testing.T) {
+	type User struct {
+		ID   int64 `pg:"id"`
+		Name string
+	}
+	type Plain struct {
+		Name string
+	}
+	var u User
+	var users []*User
+	var count int64
+	var row map[string]interface{}
+	var rows []map[string]interface{}
+	var names map[string]string
+	for _, target := range []interface{}{&u, &users, &count, &row, &rows, &names} {
+		Assert(t, ValidateTarget(target), NilVal())
+	}
+
+	Assert(t, ValidateTarget(nil), Equal(ErrTargetNotSettable))
+	Assert(t, ValidateTarget(u), Equal(ErrTargetNotSettable))
+	Assert(t, ValidateTarget((*User)(nil)), Equal(ErrTargetNotSettable))
+
+	err := ValidateTarget(&Plain{})
+	Assert(t, errors.Is(err, ErrNoMappedFields), Equal(true))
+	Assert(t, (&Scanner{AutoMap: true}).ValidateTarget(&Plain{}), NilVal())
+
+	var byID map[int]string
+	err = ValidateTarget(&byID)
+	Assert(t, errors.Is(err, ErrUnsupportedTarget), Equal(true))
+	var ch chan int
+	err = ValidateTarget(&ch)
+	Assert(t, errors.Is(err, ErrUnsupportedTarget), Equal(true))
+}